@@ -0,0 +1,53 @@
+package sortstrings
+
+import (
+	"bufio"
+	"io"
+)
+
+// check reads records from r and checks they are sorted, with Unique set
+// equal records are out of order too. Only two records are kept in memory.
+func (e *engine) check(r io.Reader) error {
+	br := bufio.NewReader(r)
+	first := 1
+	if e.Format == FormatCSV {
+		header, err := e.readRecord(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = e.resolveColumns(header); err != nil {
+			return err
+		}
+		first++
+	}
+
+	prev, err := e.readRecord(br)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for n := first + 1; ; n++ {
+		line, err := e.readRecord(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		c := e.compare(prev, line)
+		if c > 0 || (c == 0 && e.Unique) {
+			if e.Format == FormatCSV {
+				line = trimLineEnd(line)
+			}
+			return &DisorderError{Line: n, Text: line}
+		}
+		prev = line
+	}
+}
@@ -0,0 +1,50 @@
+package sortstrings
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collator compares strings with Unicode Collation Algorithm tailored for a language.
+// collate.Collator is not safe for concurrent use, so collators are pooled.
+type collator struct {
+	pool sync.Pool
+}
+
+// newCollator returns collator for locale like ru, ru-RU or POSIX style ru_RU.UTF-8
+func newCollator(locale string) (*collator, error) {
+	tag, err := parseLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &collator{
+		pool: sync.Pool{
+			New: func() interface{} {
+				return collate.New(tag)
+			},
+		},
+	}, nil
+}
+
+func (c *collator) compare(a, b string) int {
+	col := c.pool.Get().(*collate.Collator)
+	defer c.pool.Put(col)
+	return col.CompareString(a, b)
+}
+
+// parseLocale parses locale to language tag, encoding and modifier are ignored
+func parseLocale(s string) (language.Tag, error) {
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	tag, err := language.Parse(strings.ReplaceAll(s, "_", "-"))
+	if err != nil {
+		return language.Und, fmt.Errorf("unknown locale %q: %w", s, err)
+	}
+	return tag, nil
+}
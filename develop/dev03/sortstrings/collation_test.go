@@ -0,0 +1,76 @@
+package sortstrings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortText(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+		data   []string
+		want   []string
+	}{
+		{
+			desc:   "code points",
+			sorter: Sorter{},
+			data:   []string{"яма", "ёж", "Ёлка", "ель", "жук", "Ель"},
+			want:   []string{"Ёлка", "Ель", "ель", "жук", "яма", "ёж"},
+		},
+		{
+			desc:   "russian collation",
+			sorter: Sorter{Locale: "ru_RU.UTF-8"},
+			data:   []string{"яма", "ёж", "Ёлка", "ель", "жук", "Ель"},
+			want:   []string{"ёж", "Ёлка", "ель", "Ель", "жук", "яма"},
+		},
+		{
+			desc:   "fold case",
+			sorter: Sorter{Options: Options{FoldCase: true}},
+			data:   []string{"b", "A", "a", "B", "ёж", "Ёж"},
+			want:   []string{"A", "a", "B", "b", "Ёж", "ёж"},
+		},
+		{
+			desc:   "dictionary order",
+			sorter: Sorter{Options: Options{Dictionary: true}},
+			data:   []string{"b-c", "#ab", "a c", "a.d"},
+			want:   []string{"a c", "#ab", "a.d", "b-c"},
+		},
+		{
+			desc:   "dictionary order with collation",
+			sorter: Sorter{Options: Options{Dictionary: true}, Locale: "ru_RU.UTF-8"},
+			data:   []string{"«ель»", "ёж", "-жук"},
+			want:   []string{"ёж", "«ель»", "-жук"},
+		},
+		{
+			desc:   "fold case in key",
+			sorter: Sorter{Keys: mustParseKeys("2,2f")},
+			data:   []string{"1 b", "2 A", "3 a"},
+			want:   []string{"2 A", "3 a", "1 b"},
+		},
+		{
+			desc:   "versions",
+			sorter: Sorter{Options: Options{Version: true}},
+			data:   []string{"file10.txt", "v1.10", "file2.txt", "v1.9a", "file1.txt", "v1.9", "v1.0~rc1", "v1.0", "file02.txt"},
+			want:   []string{"file1.txt", "file02.txt", "file2.txt", "file10.txt", "v1.0~rc1", "v1.0", "v1.9", "v1.9a", "v1.10"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := tC.sorter.SortLines(tC.data)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	for _, in := range []string{"ru", "ru-RU", "ru_RU.UTF-8", "en_US@euro"} {
+		_, err := parseLocale(in)
+		assert.NoError(t, err, in)
+	}
+	_, err := parseLocale("not a locale")
+	assert.Error(t, err)
+}
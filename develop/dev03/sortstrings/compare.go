@@ -0,0 +1,210 @@
+package sortstrings
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wholeLine is the default key used when no keys are set
+var wholeLine = []Key{{Start: Position{Field: 1}}}
+
+// compare returns a negative number when line a goes before b, positive when after
+// and zero when lines are equal. Keys are compared in order, lines with equal keys
+// are ordered by the whole line (collated first when locale is set), so equal lines
+// are always adjacent after sorting.
+func (e *engine) compare(a, b string) int {
+	if e.bytewise {
+		if e.Options.Reverse {
+			return strings.Compare(b, a)
+		}
+		return strings.Compare(a, b)
+	}
+	if e.Format != FormatText {
+		return e.compareRecords(e.newRecord(a), e.newRecord(b))
+	}
+
+	keys := e.Keys
+	if len(keys) == 0 {
+		keys = wholeLine
+	}
+
+	for _, k := range keys {
+		opts := k.Options
+		if !k.hasOwnOptions() {
+			opts = e.Options
+		}
+
+		c := e.compareValues(k.extract(a, e.Separator, opts), k.extract(b, e.Separator, opts), k.Comparator, opts)
+		if opts.Reverse {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return e.compareLines(a, b)
+}
+
+// compareRecords compares structured records by their key values, records
+// with equal keys are ordered by the whole record
+func (e *engine) compareRecords(a, b record) int {
+	for i, k := range e.fields {
+		if c := e.compareKey(k.Key, a.values[i], b.values[i]); c != 0 {
+			return c
+		}
+	}
+
+	return e.compareLines(a.line, b.line)
+}
+
+// compareKey compares values of key extracted before
+func (e *engine) compareKey(k Key, v1, v2 string) int {
+	opts := k.Options
+	if !k.hasOwnOptions() {
+		opts = e.Options
+	}
+
+	if opts.SkipStartBlanks {
+		v1 = strings.TrimLeftFunc(v1, unicode.IsSpace)
+		v2 = strings.TrimLeftFunc(v2, unicode.IsSpace)
+	}
+	c := e.compareValues(v1, v2, k.Comparator, opts)
+	if opts.Reverse {
+		return -c
+	}
+	return c
+}
+
+// compareLines is the last resort comparison of whole lines
+func (e *engine) compareLines(a, b string) int {
+	c := e.compareText(a, b, Options{})
+	if c == 0 {
+		c = strings.Compare(a, b)
+	}
+	if e.Options.Reverse {
+		return -c
+	}
+	return c
+}
+
+// less reports whether line a goes before b
+func (e *engine) less(a, b string) bool {
+	return e.compare(a, b) < 0
+}
+
+// compareValues compares key values with comparator, or according to the order set by options
+func (e *engine) compareValues(k1, k2 string, cmp Comparator, opts Options) int {
+	switch {
+	case cmp != nil:
+		return cmp(k1, k2)
+	case opts.Numeric:
+		return compareNumeric(k1, k2)
+	case opts.General:
+		return compareGeneral(k1, k2)
+	case opts.Human:
+		return compareFloats(parseHuman(k1), parseHuman(k2))
+	case opts.Month:
+		return e.months.number(k1) - e.months.number(k2)
+	case opts.Version:
+		return compareVersions(k1, k2)
+	}
+	return e.compareText(k1, k2, opts)
+}
+
+// compareText compares strings using collation of the locale, or by code points
+// when locale is not set. Case folding and dictionary order are applied before comparison.
+func (e *engine) compareText(a, b string, opts Options) int {
+	if e.collator != nil {
+		if opts.FoldCase || opts.Dictionary {
+			a, b = transformText(a, opts), transformText(b, opts)
+		}
+		return e.collator.compare(a, b)
+	}
+
+	if !opts.FoldCase && !opts.Dictionary {
+		return strings.Compare(a, b)
+	}
+	return compareRunes(a, b, opts)
+}
+
+// compareRunes compares strings rune by rune, ignoring runes skipped by dictionary order
+// and folding case, it doesn't allocate
+func compareRunes(a, b string, opts Options) int {
+	i, j := 0, 0
+	for {
+		var r1, r2 rune = -1, -1
+		for i < len(a) {
+			r, size := utf8.DecodeRuneInString(a[i:])
+			i += size
+			if keepRune(r, opts) {
+				r1 = mapRune(r, opts)
+				break
+			}
+		}
+		for j < len(b) {
+			r, size := utf8.DecodeRuneInString(b[j:])
+			j += size
+			if keepRune(r, opts) {
+				r2 = mapRune(r, opts)
+				break
+			}
+		}
+
+		switch {
+		case r1 < r2:
+			return -1
+		case r1 > r2:
+			return 1
+		case r1 == -1:
+			return 0
+		}
+	}
+}
+
+// transformText returns string with runes that are compared by compareRunes
+func transformText(s string, opts Options) string {
+	return strings.Map(func(r rune) rune {
+		if !keepRune(r, opts) {
+			return -1
+		}
+		return mapRune(r, opts)
+	}, s)
+}
+
+// keepRune reports whether rune is compared, dictionary order compares only blanks, letters and digits
+func keepRune(r rune, opts Options) bool {
+	return !opts.Dictionary || unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// mapRune folds lower case to upper case
+func mapRune(r rune, opts Options) rune {
+	if opts.FoldCase {
+		return unicode.ToUpper(r)
+	}
+	return r
+}
+
+// skip returns index of the first rune starting from i that is not (or is, when space is false) a space
+func skip(line string, i int, space bool) int {
+	for i < len(line) {
+		if c := line[i]; c < utf8.RuneSelf {
+			if asciiSpace[c] != space {
+				break
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if unicode.IsSpace(r) != space {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// asciiSpace reports whether ASCII character is a space, it is faster than unicode.IsSpace
+var asciiSpace = [utf8.RuneSelf]bool{'\t': true, '\n': true, '\v': true, '\f': true, '\r': true, ' ': true}
@@ -0,0 +1,173 @@
+package sortstrings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortModes(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+		data   []string
+		want   []string
+	}{
+		{
+			desc:   "months",
+			sorter: Sorter{Options: Options{Month: true}},
+			data:   []string{"Dec 1", "feb", "unknown", "  JAN", "May"},
+			want:   []string{"unknown", "  JAN", "feb", "May", "Dec 1"},
+		},
+		{
+			desc:   "russian months",
+			sorter: Sorter{Options: Options{Month: true}, MonthLocale: "ru_RU.UTF-8"},
+			data:   []string{"март", "1 мая", "Январь", "мая", "декабрь", "ФЕВ"},
+			want:   []string{"1 мая", "Январь", "ФЕВ", "март", "мая", "декабрь"},
+		},
+		{
+			desc:   "russian months are unknown without locale",
+			sorter: Sorter{Options: Options{Month: true}},
+			data:   []string{"мар", "Feb", "янв"},
+			want:   []string{"мар", "янв", "Feb"},
+		},
+		{
+			desc:   "collation locale sets month names",
+			sorter: Sorter{Options: Options{Month: true}, Locale: "ru"},
+			data:   []string{"мар", "Feb", "янв"},
+			want:   []string{"янв", "Feb", "мар"},
+		},
+		{
+			desc:   "month locale overrides collation locale",
+			sorter: Sorter{Options: Options{Month: true}, Locale: "ru", MonthLocale: "de_DE"},
+			data:   []string{"Dez", "Mär", "Mai", "мар", "Okt", "jan"},
+			want:   []string{"мар", "jan", "Mär", "Mai", "Okt", "Dez"},
+		},
+		{
+			desc:   "months in key",
+			sorter: Sorter{Keys: mustParseKeys("2,2M"), MonthLocale: "ru"},
+			data:   []string{"3 мая 2020", "1 января 2021", "2 Apr 2019"},
+			want:   []string{"1 января 2021", "2 Apr 2019", "3 мая 2020"},
+		},
+		{
+			desc:   "human readable numbers",
+			sorter: Sorter{Options: Options{Human: true}},
+			data:   []string{"1.5G", "2K", "10", "1M", "900K", "", "1k"},
+			want:   []string{"", "10", "1k", "2K", "900K", "1M", "1.5G"},
+		},
+		{
+			desc:   "general numbers",
+			sorter: Sorter{Options: Options{General: true}},
+			data:   []string{"1e3", "-inf", "abc", "2.5", "nan", "-1.5E-2", "inf", "100"},
+			want:   []string{"abc", "nan", "-inf", "-1.5E-2", "2.5", "100", "1e3", "inf"},
+		},
+		{
+			desc:   "leading blanks",
+			sorter: Sorter{Options: Options{SkipStartBlanks: true, SkipEndBlanks: true}, Keys: mustParseKeys("2,2")},
+			data:   []string{"a   c", "b b", "c  a"},
+			want:   []string{"c  a", "b b", "a   c"},
+		},
+		{
+			desc:   "blanks are part of key without -b",
+			sorter: Sorter{Keys: mustParseKeys("2,2")},
+			data:   []string{"a   c", "b b", "c  a"},
+			want:   []string{"a   c", "c  a", "b b"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := tC.sorter.SortLines(tC.data)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+		input  string
+		want   *DisorderError
+	}{
+		{
+			desc:  "sorted",
+			input: "a\nb\nb\nc\n",
+		},
+		{
+			desc:  "empty",
+			input: "",
+		},
+		{
+			desc:  "disorder",
+			input: "a\nc\nb\nd\n",
+			want:  &DisorderError{Line: 3, Text: "b"},
+		},
+		{
+			desc:   "duplicate with unique",
+			sorter: Sorter{Unique: true},
+			input:  "a\nb\nb\n",
+			want:   &DisorderError{Line: 3, Text: "b"},
+		},
+		{
+			desc:   "numeric",
+			sorter: Sorter{Options: Options{Numeric: true}},
+			input:  "2\n10\n9\n",
+			want:   &DisorderError{Line: 3, Text: "9"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := tC.sorter.Check(strings.NewReader(tC.input))
+			if tC.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tC.want, err)
+		})
+	}
+}
+
+func TestTimeLocale(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		lcAll  string
+		lcTime string
+		lang   string
+		want   string
+	}{
+		{desc: "not set"},
+		{desc: "lang", lang: "ru_RU.UTF-8", want: "ru_RU.UTF-8"},
+		{desc: "lc_time overrides lang", lcTime: "de_DE.UTF-8", lang: "ru_RU.UTF-8", want: "de_DE.UTF-8"},
+		{desc: "lc_all overrides lc_time", lcAll: "fr_FR", lcTime: "de_DE.UTF-8", want: "fr_FR"},
+		{desc: "c locale", lcTime: "C", lang: "ru_RU.UTF-8"},
+		{desc: "posix locale", lcAll: "POSIX"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("LC_ALL", tC.lcAll)
+			t.Setenv("LC_TIME", tC.lcTime)
+			t.Setenv("LANG", tC.lang)
+			assert.Equal(t, tC.want, timeLocale())
+		})
+	}
+}
+
+func TestParseKeyIncompatibleOptions(t *testing.T) {
+	_, err := parseKey("1,1nM")
+	assert.Error(t, err)
+}
+
+func mustParseKeys(defs ...string) []Key {
+	keys := make([]Key, 0, len(defs))
+	for _, def := range defs {
+		k, err := ParseKey(def, FormatText)
+		if err != nil {
+			panic(err)
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
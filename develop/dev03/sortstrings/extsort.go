@@ -0,0 +1,363 @@
+package sortstrings
+
+import (
+	"bufio"
+	"container/heap"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// mergeBatch is the maximum number of runs merged at once,
+// more runs are merged in several passes
+const mergeBatch = 16
+
+// lineOverhead approximates memory used by a line besides its bytes
+const lineOverhead = 16
+
+// errStopped is returned by reader when sorting has failed
+var errStopped = errors.New("sorting stopped")
+
+// chunk is a part of input that fits in memory, last chunk may be empty
+type chunk struct {
+	lines []string
+	last  bool
+}
+
+// sortStream sorts lines from readers and writes them to w. Input is read in chunks of
+// half of bufferSize, so the next chunk is read while the previous one is sorted.
+// When input doesn't fit in one chunk, sorted chunks are written to temporary
+// files (runs) in tempDir and merged.
+func (e *engine) sortStream(readers []io.Reader, w io.Writer) error {
+	chunks := make(chan chunk)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		readErr <- e.readChunks(readers, chunks, done)
+	}()
+
+	var runs []string
+	defer func() {
+		for _, name := range runs {
+			os.Remove(name)
+		}
+	}()
+
+	for c := range chunks {
+		e.sortLines(c.lines)
+
+		if c.last && len(runs) == 0 {
+			// the whole input fits in memory
+			if err := <-readErr; err != nil {
+				return err
+			}
+			lw := e.newLineWriter(w)
+			for _, line := range c.lines {
+				if err := lw.write(line); err != nil {
+					return err
+				}
+			}
+			return lw.flush()
+		}
+		if len(c.lines) == 0 {
+			continue
+		}
+
+		name, err := e.writeRun(c.lines)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, name)
+	}
+	if err := <-readErr; err != nil {
+		return err
+	}
+
+	for len(runs) > mergeBatch {
+		name, err := e.mergeToRun(runs[:mergeBatch])
+		if err != nil {
+			return err
+		}
+		for _, merged := range runs[:mergeBatch] {
+			os.Remove(merged)
+		}
+		runs = append(runs[mergeBatch:], name)
+	}
+
+	return e.mergeRuns(runs, w)
+}
+
+// readChunks reads lines from readers one by one and sends them in chunks until input ends
+func (e *engine) readChunks(readers []io.Reader, chunks chan<- chunk, done <-chan struct{}) error {
+	defer close(chunks)
+
+	limit := e.BufferSize / 2
+	if limit < 1 {
+		limit = 1
+	}
+
+	send := func(c chunk) error {
+		select {
+		case chunks <- c:
+			return nil
+		case <-done:
+			return errStopped
+		}
+	}
+
+	var lines []string
+	var size int64
+	for _, r := range readers {
+		br := bufio.NewReader(r)
+		for {
+			line, err := e.readRecord(br)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines, line)
+			size += int64(len(line) + lineOverhead)
+			if size >= limit {
+				if err := send(chunk{lines: lines}); err != nil {
+					return err
+				}
+				lines = nil
+				size = 0
+			}
+		}
+	}
+
+	return send(chunk{lines: lines, last: true})
+}
+
+// delimiter returns byte ending records
+func (e *engine) delimiter() byte {
+	if e.ZeroTerminated {
+		return 0
+	}
+	return '\n'
+}
+
+// readLine reads line without delimiter, last line may have no delimiter.
+// Lines delimited by newline may end with \r\n.
+func readLine(r *bufio.Reader, delim byte) (string, error) {
+	line, err := r.ReadString(delim)
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if line[len(line)-1] == delim {
+		line = line[:len(line)-1]
+	}
+	if delim == '\n' {
+		line = strings.TrimSuffix(line, "\r")
+	}
+	return line, nil
+}
+
+// writeRun writes sorted lines to a new temporary file and returns its name
+func (e *engine) writeRun(lines []string) (string, error) {
+	f, err := os.CreateTemp(e.TempDir, "go-sort-*")
+	if err != nil {
+		return "", err
+	}
+
+	lw := e.newLineWriter(f)
+	for _, line := range lines {
+		if err = lw.write(line); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = lw.flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// mergeToRun merges runs into a new temporary file and returns its name
+func (e *engine) mergeToRun(names []string) (string, error) {
+	f, err := os.CreateTemp(e.TempDir, "go-sort-*")
+	if err != nil {
+		return "", err
+	}
+
+	err = e.mergeRuns(names, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// mergeRuns merges sorted runs stored in temporary files into w
+func (e *engine) mergeRuns(names []string, w io.Writer) error {
+	readers := make([]io.Reader, 0, len(names))
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+
+	return e.mergeReaders(readers, w)
+}
+
+// mergeReaders merges sorted inputs into w using heap of their current lines
+func (e *engine) mergeReaders(readers []io.Reader, w io.Writer) error {
+	h := &mergeHeap{e: e}
+	for i, r := range readers {
+		rr := &runReader{r: bufio.NewReader(r), index: i}
+		var err error
+		rr.line, err = e.readRecord(rr.r)
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h.runs = append(h.runs, rr)
+	}
+	heap.Init(h)
+
+	lw := e.newLineWriter(w)
+	for h.Len() > 0 {
+		top := h.runs[0]
+		if err := lw.write(top.line); err != nil {
+			return err
+		}
+
+		line, err := e.readRecord(top.r)
+		if err == io.EOF {
+			heap.Pop(h)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		top.line = line
+		heap.Fix(h, 0)
+	}
+
+	return lw.flush()
+}
+
+// runReader is a sorted run with its current line
+type runReader struct {
+	r     *bufio.Reader
+	line  string
+	index int
+}
+
+// mergeHeap keeps runs ordered by their current lines, runs with
+// equal lines are ordered by index to keep merge stable
+type mergeHeap struct {
+	runs []*runReader
+	e    *engine
+}
+
+func (h *mergeHeap) Len() int {
+	return len(h.runs)
+}
+
+func (h *mergeHeap) Less(i, j int) bool {
+	c := h.e.compare(h.runs[i].line, h.runs[j].line)
+	if c == 0 {
+		return h.runs[i].index < h.runs[j].index
+	}
+	return c < 0
+}
+
+func (h *mergeHeap) Swap(i, j int) {
+	h.runs[i], h.runs[j] = h.runs[j], h.runs[i]
+}
+
+func (h *mergeHeap) Push(x interface{}) {
+	h.runs = append(h.runs, x.(*runReader))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	last := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return last
+}
+
+// lineWriter writes lines ending with delimiter, with unique set
+// equal adjacent lines are written once. CSV records have their own line endings.
+type lineWriter struct {
+	w       *bufio.Writer
+	delim   byte
+	raw     bool
+	unique  bool
+	prev    string
+	started bool
+}
+
+func (e *engine) newLineWriter(w io.Writer) *lineWriter {
+	return &lineWriter{w: bufio.NewWriter(w), delim: e.delimiter(), raw: e.Format == FormatCSV, unique: e.Unique}
+}
+
+func (lw *lineWriter) write(line string) error {
+	if lw.unique && lw.started && line == lw.prev {
+		return nil
+	}
+	lw.prev = line
+	lw.started = true
+
+	if _, err := lw.w.WriteString(line); err != nil || lw.raw {
+		return err
+	}
+	return lw.w.WriteByte(lw.delim)
+}
+
+func (lw *lineWriter) flush() error {
+	return lw.w.Flush()
+}
+
+// process sorts or merges inputs, CSV header is written first
+func (e *engine) process(readers []io.Reader, w io.Writer, merge bool) error {
+	if e.Format == FormatCSV {
+		var err error
+		readers, err = e.readHeaders(readers, w)
+		if err != nil {
+			return err
+		}
+	}
+
+	if merge {
+		return e.mergeReaders(readers, w)
+	}
+	return e.sortStream(readers, w)
+}
+
+// sort sorts lines in memory, with Unique set equal lines are left once
+func (e *engine) sort(data []string) []string {
+	e.sortLines(data)
+
+	if e.Unique {
+		data = delDuplicate(data)
+	}
+
+	return data
+}
@@ -0,0 +1,112 @@
+package sortstrings
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortStream(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]string, 0, 2000)
+	for i := 0; i < cap(data); i++ {
+		data = append(data, fmt.Sprintf("line%d %d", rnd.Intn(500), rnd.Intn(100)))
+	}
+	input := strings.Join(data, "\n") + "\n"
+
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+	}{
+		{
+			desc: "whole lines",
+		},
+		{
+			desc: "by 2nd column, numeric",
+			sorter: Sorter{
+				Keys:    []Key{{Start: Position{Field: 2}}},
+				Options: Options{Numeric: true},
+			},
+		},
+		{
+			desc: "reverse, delete duplicate",
+			sorter: Sorter{
+				Options: Options{Reverse: true},
+				Unique:  true,
+			},
+		},
+	}
+	for _, tC := range testCases {
+		for _, size := range []int64{1 << 20, 1 << 10, 64} {
+			t.Run(fmt.Sprintf("%s, buffer %d", tC.desc, size), func(t *testing.T) {
+				dir := t.TempDir()
+				s := tC.sorter
+				s.BufferSize = size
+				s.TempDir = dir
+
+				want, err := s.SortLines(append([]string(nil), data...))
+				require.NoError(t, err)
+
+				var out bytes.Buffer
+				err = s.Sort(&out, strings.NewReader(input))
+				require.NoError(t, err)
+				assert.Equal(t, strings.Join(want, "\n")+"\n", out.String())
+
+				files, err := os.ReadDir(dir)
+				require.NoError(t, err)
+				assert.Empty(t, files, "temporary files should be removed")
+			})
+		}
+	}
+}
+
+func TestSortStreamLineEndings(t *testing.T) {
+	s := Sorter{BufferSize: 1, TempDir: t.TempDir()}
+
+	var out bytes.Buffer
+	err := s.Sort(&out, strings.NewReader("b\r\nc\na"))
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\nc\n", out.String())
+
+	out.Reset()
+	err = s.Sort(&out, strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Equal(t, "", out.String())
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "10", want: 10 << 10},
+		{in: "10b", want: 10},
+		{in: "10K", want: 10 << 10},
+		{in: "3M", want: 3 << 20},
+		{in: "2G", want: 2 << 30},
+		{in: "1T", want: 1 << 40},
+		{in: "", wantErr: true},
+		{in: "M", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "10x", wantErr: true},
+		{in: "99999999999T", wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.in, func(t *testing.T) {
+			got, err := parseSize(tC.in)
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
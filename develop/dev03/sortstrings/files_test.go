@@ -0,0 +1,125 @@
+package sortstrings
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortStreamMultipleInputs(t *testing.T) {
+	var s Sorter
+	var out bytes.Buffer
+	err := s.Sort(&out,
+		strings.NewReader("c\na"),
+		strings.NewReader("b\n"),
+		strings.NewReader(""),
+		strings.NewReader("d"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\nc\nd\n", out.String())
+}
+
+func TestMergeReaders(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+		inputs []string
+		want   string
+	}{
+		{
+			desc:   "sorted inputs",
+			inputs: []string{"a\nc\ne\n", "b\nd", "", "a\nf\n"},
+			want:   "a\na\nb\nc\nd\ne\nf\n",
+		},
+		{
+			desc:   "unique",
+			sorter: Sorter{Unique: true},
+			inputs: []string{"a\nb\n", "a\nb\nc\n"},
+			want:   "a\nb\nc\n",
+		},
+		{
+			desc:   "numeric reverse",
+			sorter: Sorter{Options: Options{Numeric: true, Reverse: true}},
+			inputs: []string{"10\n2\n", "5\n1\n"},
+			want:   "10\n5\n2\n1\n",
+		},
+		{
+			desc:   "zero terminated",
+			sorter: Sorter{ZeroTerminated: true},
+			inputs: []string{"a\nb\x00c\x00", "b\x00"},
+			want:   "a\nb\x00b\x00c\x00",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			readers := make([]io.Reader, 0, len(tC.inputs))
+			for _, in := range tC.inputs {
+				readers = append(readers, strings.NewReader(in))
+			}
+
+			var out bytes.Buffer
+			err := tC.sorter.Merge(&out, readers...)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, out.String())
+		})
+	}
+}
+
+func TestSortStreamZeroTerminated(t *testing.T) {
+	s := Sorter{ZeroTerminated: true, BufferSize: 1, TempDir: t.TempDir()}
+
+	var out bytes.Buffer
+	err := s.Sort(&out, strings.NewReader("line 2\nwith newline\x00line 1\x00line 3"))
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\x00line 2\nwith newline\x00line 3\x00", out.String())
+}
+
+func TestCLIOutputToInput(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	require.NoError(t, os.WriteFile(first, []byte("c\na\n"), 0o600))
+	require.NoError(t, os.WriteFile(second, []byte("b\n"), 0o600))
+
+	code := CLI([]string{"-o", first, first, second})
+	require.Equal(t, 0, code)
+
+	got, err := os.ReadFile(first)
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\nc\n", string(got))
+
+	stat, err := os.Stat(first)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "temporary output should be renamed")
+}
+
+func TestCLIMerge(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	out := filepath.Join(dir, "out.txt")
+	require.NoError(t, os.WriteFile(first, []byte("b\nd\n"), 0o600))
+	require.NoError(t, os.WriteFile(second, []byte("a\nc\n"), 0o600))
+
+	code := CLI([]string{"-m", "-o" + out, first, second})
+	require.Equal(t, 0, code)
+
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\nc\nd\n", string(got))
+}
+
+func TestCLIMissingFile(t *testing.T) {
+	code := CLI([]string{filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Equal(t, 2, code)
+}
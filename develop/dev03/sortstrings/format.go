@@ -0,0 +1,303 @@
+package sortstrings
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseFormat parses format name used by --format
+func parseFormat(s string) (Format, error) {
+	switch s {
+	case "", "text":
+		return FormatText, nil
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl":
+		return FormatJSONL, nil
+	}
+	return FormatText, fmt.Errorf("unknown format %q, use text, csv or jsonl", s)
+}
+
+// fieldKey is a key of structured record with resolved CSV column or parsed JSON path
+type fieldKey struct {
+	Key
+	column int
+	path   []pathElem
+}
+
+// pathElem is an object member or, when index is not negative, an array element
+type pathElem struct {
+	name  string
+	index int
+}
+
+// parseFieldKey parses structured key definition NAME[:OPTS]
+func parseFieldKey(s string) (Key, error) {
+	k := Key{Field: s}
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		var opts Options
+		known := true
+		for _, r := range s[i+1:] {
+			known = known && opts.set(r, false)
+		}
+		if known {
+			k.Field = s[:i]
+			k.Options = opts
+		}
+	}
+	if k.Field == "" {
+		return Key{}, fmt.Errorf("invalid key %q: empty name", s)
+	}
+	if err := k.Options.validate(); err != nil {
+		return Key{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	return k, nil
+}
+
+// newFieldKey prepares key of structured record, JSON path is parsed once here
+func newFieldKey(k Key, format Format) (fieldKey, error) {
+	fk := fieldKey{Key: k}
+	if k.Field == "" {
+		return fk, fmt.Errorf("key of %s record should have a field", format)
+	}
+	if err := k.Options.validate(); err != nil {
+		return fk, fmt.Errorf("invalid key %q: %w", k.Field, err)
+	}
+
+	if format == FormatJSONL {
+		var err error
+		fk.path, err = parseJSONPath(k.Field)
+		if err != nil {
+			return fk, fmt.Errorf("invalid key %q: %w", k.Field, err)
+		}
+	}
+	return fk, nil
+}
+
+// parseJSONPath parses path like .items[0].id, single dot means the whole value
+func parseJSONPath(s string) ([]pathElem, error) {
+	if s == "." {
+		return nil, nil
+	}
+
+	var path []pathElem
+	for s != "" {
+		switch s[0] {
+		case '.':
+			end := strings.IndexAny(s[1:], ".[")
+			if end < 0 {
+				end = len(s) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty member name in JSON path")
+			}
+			path = append(path, pathElem{name: s[1 : end+1], index: -1})
+			s = s[end+1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in JSON path")
+			}
+			index, err := strconv.Atoi(s[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid array index %q in JSON path", s[1:end])
+			}
+			path = append(path, pathElem{index: index})
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("JSON path should start with . or [")
+		}
+	}
+	return path, nil
+}
+
+// record is a line with values of its keys extracted once
+type record struct {
+	line   string
+	values []string
+}
+
+// newRecord extracts values of keys from structured record,
+// values of missing fields and records that can't be parsed are empty
+func (e *engine) newRecord(line string) record {
+	r := record{line: line, values: make([]string, len(e.fields))}
+
+	switch e.Format {
+	case FormatCSV:
+		fields := e.parseCSV(line)
+		for i, k := range e.fields {
+			if k.column < len(fields) {
+				r.values[i] = fields[k.column]
+			}
+		}
+	case FormatJSONL:
+		var v interface{}
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber()
+		if dec.Decode(&v) != nil {
+			return r
+		}
+		for i, k := range e.fields {
+			r.values[i] = jsonString(lookupJSON(v, k.path))
+		}
+	}
+
+	return r
+}
+
+// parseCSV parses CSV record, -t sets field separator
+func (e *engine) parseCSV(line string) []string {
+	r := csv.NewReader(strings.NewReader(line))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if e.Separator != 0 {
+		r.Comma = e.Separator
+	}
+
+	fields, err := r.Read()
+	if err != nil {
+		return nil
+	}
+	return fields
+}
+
+// resolveColumns finds key columns in CSV header, keys may refer to columns by number too
+func (e *engine) resolveColumns(header string) error {
+	names := e.parseCSV(header)
+	for i := range e.fields {
+		k := &e.fields[i]
+		k.column = -1
+		for j, name := range names {
+			if name == k.Field {
+				k.column = j
+				break
+			}
+		}
+		if k.column >= 0 {
+			continue
+		}
+
+		n, err := strconv.Atoi(k.Field)
+		if err != nil || n < 1 {
+			return fmt.Errorf("unknown column %q", k.Field)
+		}
+		k.column = n - 1
+	}
+	return nil
+}
+
+// readRecord reads record, CSV record may contain quoted line endings. Text and JSONL
+// records are returned without line ending. CSV records are kept as is with their
+// line ending, \r\n or \n, so they are written unchanged, the last record without
+// line ending gets \n.
+func (e *engine) readRecord(r *bufio.Reader) (string, error) {
+	if e.Format != FormatCSV {
+		return readLine(r, e.delimiter())
+	}
+
+	var sb strings.Builder
+	quotes := 0
+	for {
+		part, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		sb.WriteString(part)
+		quotes += strings.Count(part, `"`)
+		if err == io.EOF {
+			if sb.Len() == 0 {
+				return "", io.EOF
+			}
+			sb.WriteByte('\n')
+			break
+		}
+		if quotes%2 == 0 {
+			break
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// trimLineEnd returns CSV record without its line ending
+func trimLineEnd(rec string) string {
+	rec = strings.TrimSuffix(rec, "\n")
+	return strings.TrimSuffix(rec, "\r")
+}
+
+// readHeaders reads CSV header of every input, resolves key columns with the first
+// header and writes it to w. Returned readers continue after headers.
+func (e *engine) readHeaders(readers []io.Reader, w io.Writer) ([]io.Reader, error) {
+	res := make([]io.Reader, 0, len(readers))
+	found := false
+	for _, r := range readers {
+		br := bufio.NewReader(r)
+		res = append(res, br)
+
+		header, err := e.readRecord(br)
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			continue
+		}
+
+		found = true
+		if err = e.resolveColumns(header); err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(w, header); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func lookupJSON(v interface{}, path []pathElem) interface{} {
+	for _, elem := range path {
+		if elem.index >= 0 {
+			arr, ok := v.([]interface{})
+			if !ok || elem.index >= len(arr) {
+				return nil
+			}
+			v = arr[elem.index]
+			continue
+		}
+
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[elem.name]
+	}
+	return v
+}
+
+// jsonString returns string value of JSON value, objects and arrays are encoded back to JSON
+func jsonString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(buf)
+}
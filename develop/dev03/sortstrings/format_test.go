@@ -0,0 +1,195 @@
+package sortstrings
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldKey(t *testing.T) {
+	testCases := []struct {
+		in      string
+		format  Format
+		want    Key
+		wantErr bool
+	}{
+		{
+			in:     "name",
+			format: FormatCSV,
+			want:   Key{Field: "name"},
+		},
+		{
+			in:     "age:nr",
+			format: FormatCSV,
+			want:   Key{Field: "age", Options: Options{Numeric: true, Reverse: true}},
+		},
+		{
+			in:     "time: utc",
+			format: FormatCSV,
+			want:   Key{Field: "time: utc"},
+		},
+		{
+			in:     ".ts:n",
+			format: FormatJSONL,
+			want:   Key{Field: ".ts", Options: Options{Numeric: true}},
+		},
+		{
+			in:     ".items[1].id",
+			format: FormatJSONL,
+			want:   Key{Field: ".items[1].id"},
+		},
+		{in: ":n", format: FormatCSV, wantErr: true},
+		{in: "a:nM", format: FormatCSV, wantErr: true},
+		{in: "ts", format: FormatJSONL, wantErr: true},
+		{in: ".a[x]", format: FormatJSONL, wantErr: true},
+		{in: ".a[1", format: FormatJSONL, wantErr: true},
+		{in: ".a..b", format: FormatJSONL, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.in, func(t *testing.T) {
+			got, err := ParseKey(tC.in, tC.format)
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	got, err := parseJSONPath(".items[1].id")
+	require.NoError(t, err)
+	assert.Equal(t, []pathElem{{name: "items", index: -1}, {index: 1}, {name: "id", index: -1}}, got)
+}
+
+func TestSortStructured(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+		keys   []string
+		inputs []string
+		want   string
+	}{
+		{
+			desc:   "csv by column name",
+			sorter: Sorter{Format: FormatCSV},
+			keys:   []string{"age:n", "name"},
+			inputs: []string{
+				"name,age,city\n" +
+					"\"Smith, John\",42,London\n" +
+					"bob,7,\"New\nYork\"\n" +
+					"alice,42,Paris\n",
+			},
+			want: "name,age,city\n" +
+				"bob,7,\"New\nYork\"\n" +
+				"\"Smith, John\",42,London\n" +
+				"alice,42,Paris\n",
+		},
+		{
+			desc:   "csv by column number with separator, several files",
+			sorter: Sorter{Format: FormatCSV, Separator: ';', Options: Options{Reverse: true}},
+			keys:   []string{"2"},
+			inputs: []string{
+				"name;city\r\na;Berlin\r\n",
+				"name;city\nb;Amsterdam\nc;Cairo",
+			},
+			want: "name;city\r\n" +
+				"c;Cairo\n" +
+				"a;Berlin\r\n" +
+				"b;Amsterdam\n",
+		},
+		{
+			desc:   "csv with crlf line endings",
+			sorter: Sorter{Format: FormatCSV, Unique: true},
+			keys:   []string{"city"},
+			inputs: []string{
+				"name,city\r\n" +
+					"bob,\"New\r\nYork\"\r\n" +
+					"carol,\"Paris\r\"\r\n" +
+					"alice,Berlin\r\n" +
+					"alice,Berlin\r\n",
+			},
+			want: "name,city\r\n" +
+				"alice,Berlin\r\n" +
+				"bob,\"New\r\nYork\"\r\n" +
+				"carol,\"Paris\r\"\r\n",
+		},
+		{
+			desc:   "jsonl by path",
+			sorter: Sorter{Format: FormatJSONL},
+			keys:   []string{".ts:n", ".user.name:r"},
+			inputs: []string{
+				`{"ts": 20, "user": {"name": "bob"}, "msg": "b"}` + "\n" +
+					`{"user": {"name": "eve"}, "ts": 3}` + "\n" +
+					`not json` + "\n" +
+					`{"ts":20,"user":{"name":"carol"}}` + "\n",
+			},
+			want: `not json` + "\n" +
+				`{"user": {"name": "eve"}, "ts": 3}` + "\n" +
+				`{"ts":20,"user":{"name":"carol"}}` + "\n" +
+				`{"ts": 20, "user": {"name": "bob"}, "msg": "b"}` + "\n",
+		},
+		{
+			desc:   "jsonl by array element",
+			sorter: Sorter{Format: FormatJSONL},
+			keys:   []string{".tags[1]"},
+			inputs: []string{
+				`{"tags": ["x", "b"]}` + "\n" +
+					`{"tags": ["y", "a"]}` + "\n" +
+					`{"tags": ["z"]}` + "\n",
+			},
+			want: `{"tags": ["z"]}` + "\n" +
+				`{"tags": ["y", "a"]}` + "\n" +
+				`{"tags": ["x", "b"]}` + "\n",
+		},
+	}
+	for _, tC := range testCases {
+		for _, size := range []int64{1 << 20, 1} {
+			tC, size := tC, size
+			t.Run(tC.desc, func(t *testing.T) {
+				s := tC.sorter
+				s.BufferSize = size
+				s.TempDir = t.TempDir()
+				for _, def := range tC.keys {
+					k, err := ParseKey(def, s.Format)
+					require.NoError(t, err)
+					s.Keys = append(s.Keys, k)
+				}
+
+				readers := make([]io.Reader, 0, len(tC.inputs))
+				for _, in := range tC.inputs {
+					readers = append(readers, strings.NewReader(in))
+				}
+
+				var out bytes.Buffer
+				err := s.Sort(&out, readers...)
+				require.NoError(t, err)
+				assert.Equal(t, tC.want, out.String())
+			})
+		}
+	}
+}
+
+func TestSortStructuredUnknownColumn(t *testing.T) {
+	s := Sorter{Format: FormatCSV, Keys: []Key{{Field: "missing"}}}
+
+	var out bytes.Buffer
+	err := s.Sort(&out, strings.NewReader("name,age\na,1\n"))
+	assert.Error(t, err)
+}
+
+func TestCheckCSV(t *testing.T) {
+	s := Sorter{Format: FormatCSV, Keys: []Key{{Field: "age", Options: Options{Numeric: true}}}}
+
+	err := s.Check(strings.NewReader("name,age\nb,2\na,10\nc,3\n"))
+	assert.Equal(t, &DisorderError{Line: 4, Text: "c,3"}, err)
+
+	err = s.Check(strings.NewReader("name,age\r\nb,2\r\na,10\r\nc,3\r\n"))
+	assert.Equal(t, &DisorderError{Line: 4, Text: "c,3"}, err)
+}
@@ -0,0 +1,181 @@
+package sortstrings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// set sets option by its letter as in -k 2,2nr, b sets skipping blanks
+// at the end when isEnd is true. It reports whether the option is known.
+func (o *Options) set(r rune, isEnd bool) bool {
+	switch r {
+	case 'b':
+		if isEnd {
+			o.SkipEndBlanks = true
+		} else {
+			o.SkipStartBlanks = true
+		}
+	case 'n':
+		o.Numeric = true
+	case 'g':
+		o.General = true
+	case 'h':
+		o.Human = true
+	case 'M':
+		o.Month = true
+	case 'V':
+		o.Version = true
+	case 'f':
+		o.FoldCase = true
+	case 'd':
+		o.Dictionary = true
+	case 'r':
+		o.Reverse = true
+	default:
+		return false
+	}
+	return true
+}
+
+// validate checks that only one of numeric, general, human, month and version orders is set
+func (o Options) validate() error {
+	n := 0
+	for _, set := range []bool{o.Numeric, o.General, o.Human, o.Month, o.Version} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("options n, g, h, M and V are incompatible")
+	}
+	return nil
+}
+
+// parseKey parses text key definition POS1[,POS2], where POS is F[.C][OPTS]
+func parseKey(s string) (Key, error) {
+	var k Key
+	start, end, hasEnd := strings.Cut(s, ",")
+
+	var err error
+	k.Start, err = k.parsePosition(start, false)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid key start %q: %w", start, err)
+	}
+	if hasEnd {
+		k.End, err = k.parsePosition(end, true)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid key end %q: %w", end, err)
+		}
+	}
+	if err = k.Options.validate(); err != nil {
+		return Key{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+
+	return k, nil
+}
+
+// parsePosition parses F[.C][OPTS] and sets key options found in it
+func (k *Key) parsePosition(s string, isEnd bool) (Position, error) {
+	var p Position
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, opts := s[:i], s[i:]
+
+	fieldNum, charNum, hasChar := strings.Cut(num, ".")
+	var err error
+	p.Field, err = strconv.Atoi(fieldNum)
+	if err != nil || p.Field < 1 {
+		return p, fmt.Errorf("field number should be positive")
+	}
+	if hasChar {
+		p.Char, err = strconv.Atoi(charNum)
+		if err != nil || p.Char < 0 || (!isEnd && p.Char == 0) {
+			return p, fmt.Errorf("invalid character number")
+		}
+	}
+
+	for _, r := range opts {
+		if !k.Options.set(r, isEnd) {
+			return p, fmt.Errorf("unknown option %q", r)
+		}
+	}
+
+	return p, nil
+}
+
+// hasOwnOptions reports whether key has options or comparator,
+// global options are not used for such key
+func (k Key) hasOwnOptions() bool {
+	return k.Comparator != nil || k.Options != (Options{})
+}
+
+// extract returns key part of the line. Fields are separated by sep,
+// or, when sep is zero, by empty strings before blanks, so fields include their leading blanks.
+func (k Key) extract(line string, sep rune, opts Options) string {
+	start := fieldStart(line, k.Start.Field-1, sep)
+	if opts.SkipStartBlanks {
+		start = skip(line, start, true)
+	}
+	start = advance(line, start, k.Start.Char-1)
+
+	end := len(line)
+	if k.End.Field > 0 {
+		end = fieldStart(line, k.End.Field-1, sep)
+		if k.End.Char == 0 {
+			end = fieldEnd(line, end, sep)
+		} else {
+			if opts.SkipEndBlanks {
+				end = skip(line, end, true)
+			}
+			end = advance(line, end, k.End.Char)
+		}
+	}
+
+	if end <= start {
+		return ""
+	}
+	return line[start:end]
+}
+
+// fieldStart returns index of the n-th (0-based) field start, or length of line when there is no such field
+func fieldStart(line string, n int, sep rune) int {
+	i := 0
+	for ; n > 0 && i < len(line); n-- {
+		i = fieldEnd(line, i, sep)
+		if sep != 0 && i < len(line) {
+			i += utf8.RuneLen(sep)
+		}
+	}
+	if n > 0 {
+		return len(line)
+	}
+	return i
+}
+
+// fieldEnd returns index of the end of field starting at i
+func fieldEnd(line string, i int, sep rune) int {
+	if sep == 0 {
+		return skip(line, skip(line, i, true), false)
+	}
+
+	end := strings.IndexRune(line[i:], sep)
+	if end < 0 {
+		return len(line)
+	}
+	return i + end
+}
+
+// advance returns index n runes after i, it stops at the end of line
+func advance(line string, i, n int) int {
+	for ; n > 0 && i < len(line); n-- {
+		_, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+	}
+	return i
+}
@@ -0,0 +1,170 @@
+package sortstrings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKey(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    Key
+		wantErr bool
+	}{
+		{
+			in:   "2",
+			want: Key{Start: Position{Field: 2}},
+		},
+		{
+			in:   "2,2",
+			want: Key{Start: Position{Field: 2}, End: Position{Field: 2}},
+		},
+		{
+			in: "2.3,4.5",
+			want: Key{
+				Start: Position{Field: 2, Char: 3},
+				End:   Position{Field: 4, Char: 5},
+			},
+		},
+		{
+			in: "2,2nr",
+			want: Key{
+				Start:   Position{Field: 2},
+				End:     Position{Field: 2},
+				Options: Options{Numeric: true, Reverse: true},
+			},
+		},
+		{
+			in: "1.2b,3b",
+			want: Key{
+				Start:   Position{Field: 1, Char: 2},
+				End:     Position{Field: 3},
+				Options: Options{SkipStartBlanks: true, SkipEndBlanks: true},
+			},
+		},
+		{in: "", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "1.0", wantErr: true},
+		{in: "1,x", wantErr: true},
+		{in: "1z", wantErr: true},
+		{in: "a", wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.in, func(t *testing.T) {
+			got, err := parseKey(tC.in)
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestKeyExtract(t *testing.T) {
+	testCases := []struct {
+		desc string
+		key  string
+		sep  rune
+		line string
+		want string
+	}{
+		{desc: "field to end of line", key: "2", line: "a  b c", want: "  b c"},
+		{desc: "single field", key: "2,2", line: "a  b c", want: "  b"},
+		{desc: "single field skipping blanks", key: "2b,2", line: "a  b c", want: "b"},
+		{desc: "characters", key: "1.2,1.3", line: "abcd e", want: "bc"},
+		{desc: "characters skipping blanks", key: "2.2b,2.3b", line: "a  bcd", want: "cd"},
+		{desc: "unicode characters", key: "1.2,1.3", line: "ёжик", want: "жи"},
+		{desc: "missing field", key: "3", line: "a b", want: ""},
+		{desc: "end before start", key: "2,1", line: "a b", want: ""},
+		{desc: "separator", key: "2,2", sep: ':', line: "a:b c:d", want: "b c"},
+		{desc: "empty field", key: "2,2", sep: ':', line: "a::d", want: ""},
+		{desc: "separator to end of line", key: "2", sep: ':', line: "a:b:c", want: "b:c"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			k, err := parseKey(tC.key)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, k.extract(tC.line, tC.sep, k.Options))
+		})
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	data := []string{
+		"bob:20:london",
+		"alice:30:paris",
+		"carol:20:berlin",
+		"dave:100:paris",
+		"alice:30:berlin",
+	}
+
+	testCases := []struct {
+		desc      string
+		separator rune
+		isNumeric bool
+		keys      []string
+		want      []string
+	}{
+		{
+			desc:      "numeric reverse key then name",
+			separator: ':',
+			keys:      []string{"2,2nr", "1,1"},
+			want: []string{
+				"dave:100:paris",
+				"alice:30:berlin",
+				"alice:30:paris",
+				"bob:20:london",
+				"carol:20:berlin",
+			},
+		},
+		{
+			desc:      "global options apply to keys without own options",
+			separator: ':',
+			isNumeric: true,
+			keys:      []string{"2,2", "3,3r"},
+			want: []string{
+				"bob:20:london",
+				"carol:20:berlin",
+				"alice:30:paris",
+				"alice:30:berlin",
+				"dave:100:paris",
+			},
+		},
+		{
+			desc:      "ties are ordered by whole line",
+			separator: ':',
+			keys:      []string{"3,3"},
+			want: []string{
+				"alice:30:berlin",
+				"carol:20:berlin",
+				"bob:20:london",
+				"alice:30:paris",
+				"dave:100:paris",
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s := Sorter{Separator: tC.separator, Options: Options{Numeric: tC.isNumeric}}
+			for _, v := range tC.keys {
+				k, err := ParseKey(v, FormatText)
+				require.NoError(t, err)
+				s.Keys = append(s.Keys, k)
+			}
+
+			got, err := s.SortLines(append([]string(nil), data...))
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestSplitShortFlags(t *testing.T) {
+	got := splitShortFlags([]string{"-k2,2nr", "-k", "1", "-t:", "-k=3", "-n", "--", "-kfile"})
+	want := []string{"-k", "2,2nr", "-k", "1", "-t", ":", "-k=3", "-n", "--", "-kfile"}
+	assert.Equal(t, want, got)
+}
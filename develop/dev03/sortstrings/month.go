@@ -0,0 +1,71 @@
+package sortstrings
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// monthsByLanguage are lower case beginnings of month names by language, names in genitive case
+// are listed too when they begin differently (май, мая). English names are used by every locale.
+var monthsByLanguage = map[string][12][]string{
+	"en": {{"jan"}, {"feb"}, {"mar"}, {"apr"}, {"may"}, {"jun"}, {"jul"}, {"aug"}, {"sep"}, {"oct"}, {"nov"}, {"dec"}},
+	"ru": {{"янв"}, {"фев"}, {"мар"}, {"апр"}, {"май", "мая"}, {"июн"}, {"июл"}, {"авг"}, {"сен"}, {"окт"}, {"ноя"}, {"дек"}},
+	"uk": {{"січ"}, {"лют"}, {"бер"}, {"кві"}, {"тра"}, {"чер"}, {"лип"}, {"сер"}, {"вер"}, {"жов"}, {"лис"}, {"гру"}},
+	"de": {{"jan"}, {"feb"}, {"mär", "mrz"}, {"apr"}, {"mai"}, {"jun"}, {"jul"}, {"aug"}, {"sep"}, {"okt"}, {"nov"}, {"dez"}},
+	"fr": {{"janv"}, {"févr"}, {"mars"}, {"avr"}, {"mai"}, {"juin"}, {"juil"}, {"août"}, {"sept"}, {"oct"}, {"nov"}, {"déc"}},
+	"es": {{"ene"}, {"feb"}, {"mar"}, {"abr"}, {"may"}, {"jun"}, {"jul"}, {"ago"}, {"sep", "set"}, {"oct"}, {"nov"}, {"dic"}},
+}
+
+// months recognizes month names of a locale
+type months [12][]string
+
+// newMonths returns month names of locale language with English ones,
+// only English names are recognized for empty locale or unknown language
+func newMonths(locale string) (months, error) {
+	m := months(monthsByLanguage["en"])
+	if locale == "" {
+		return m, nil
+	}
+
+	tag, err := parseLocale(locale)
+	if err != nil {
+		return m, err
+	}
+	base, _ := tag.Base()
+	if names, ok := monthsByLanguage[base.String()]; ok && base.String() != "en" {
+		for i := range m {
+			m[i] = append(names[i], m[i]...)
+		}
+	}
+	return m, nil
+}
+
+// number returns number of month the value starts with (1 for January),
+// zero is returned for unknown months, so they go before January
+func (m *months) number(s string) int {
+	s = strings.ToLower(strings.TrimLeftFunc(s, unicode.IsSpace))
+	for i, prefixes := range m {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(s, prefix) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// timeLocale returns locale of month names from environment like setlocale(LC_TIME):
+// LC_ALL overrides LC_TIME, LANG is used when both are empty.
+// Empty string is returned for C, POSIX and unknown locales.
+func timeLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if _, err := parseLocale(v); err != nil {
+				return ""
+			}
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package sortstrings
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// compareNumeric compares integer values, values that can't be parsed are compared as strings
+func compareNumeric(k1, k2 string) int {
+	k1 = strings.TrimLeftFunc(k1, unicode.IsSpace)
+	k2 = strings.TrimLeftFunc(k2, unicode.IsSpace)
+
+	i1, err := strconv.Atoi(trimNonNumber(k1))
+	if err != nil {
+		return strings.Compare(k1, k2)
+	}
+	j1, err := strconv.Atoi(trimNonNumber(k2))
+	if err != nil {
+		return strings.Compare(k1, k2)
+	}
+
+	switch {
+	case i1 < j1:
+		return -1
+	case i1 > j1:
+		return 1
+	}
+	return 0
+}
+
+// compareFloats compares numbers like GNU sort -g does: NaN goes before all numbers
+func compareFloats(a, b float64) int {
+	aNaN, bNaN := math.IsNaN(a), math.IsNaN(b)
+	switch {
+	case aNaN && bNaN:
+		return 0
+	case aNaN:
+		return -1
+	case bNaN:
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareGeneral compares leading floating point numbers like 1.5e3, -inf or nan,
+// values without a number go before all numbers
+func compareGeneral(k1, k2 string) int {
+	v1, ok1 := parseGeneral(k1)
+	v2, ok2 := parseGeneral(k2)
+	switch {
+	case !ok1 && !ok2:
+		return 0
+	case !ok1:
+		return -1
+	case !ok2:
+		return 1
+	}
+	return compareFloats(v1, v2)
+}
+
+// parseGeneral parses leading floating point number, it reports whether there is a number
+func parseGeneral(s string) (float64, bool) {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	n := floatPrefix(s)
+	if n == 0 {
+		return 0, false
+	}
+
+	v, err := strconv.ParseFloat(s[:n], 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, false
+	}
+	return v, true
+}
+
+// humanSuffixes are multipliers of human readable numbers, each is 1024 times bigger than previous
+const humanSuffixes = "KMGTPEZY"
+
+// parseHuman parses human readable number like 2K or 1.5G,
+// values without a number are equal to zero
+func parseHuman(s string) float64 {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	n := decimalPrefix(s)
+	if n == 0 {
+		return 0
+	}
+
+	v, err := strconv.ParseFloat(s[:n], 64)
+	if err != nil {
+		return 0
+	}
+	if n < len(s) {
+		suffix := s[n]
+		if suffix == 'k' {
+			suffix = 'K'
+		}
+		if i := strings.IndexByte(humanSuffixes, suffix); i >= 0 {
+			v *= math.Pow(1024, float64(i+1))
+		}
+	}
+	return v
+}
+
+// floatPrefix returns length of leading floating point number, infinity or NaN
+func floatPrefix(s string) int {
+	rest := strings.TrimLeft(s, "+-")
+	if len(s)-len(rest) > 1 {
+		return 0
+	}
+	lower := strings.ToLower(rest)
+	for _, word := range []string{"infinity", "inf", "nan"} {
+		if strings.HasPrefix(lower, word) {
+			return len(s) - len(rest) + len(word)
+		}
+	}
+
+	n := decimalPrefix(s)
+	if n == 0 || n == len(s) || (s[n] != 'e' && s[n] != 'E') {
+		return n
+	}
+
+	i := n + 1
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := i
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == digits {
+		return n
+	}
+	return i
+}
+
+// decimalPrefix returns length of leading decimal number with optional sign and fraction
+func decimalPrefix(s string) int {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0
+	}
+	return i
+}
@@ -0,0 +1,119 @@
+package sortstrings
+
+import (
+	"sort"
+	"sync"
+)
+
+// minParallelLines is the minimum number of lines sorted by one goroutine,
+// smaller inputs are sorted by fewer goroutines
+const minParallelLines = 1 << 12
+
+// sortLines sorts lines in place. Lines that compare equal are identical,
+// so there is no need in stable sort. Keys of structured records are extracted
+// once before sorting, not on every comparison.
+func (e *engine) sortLines(lines []string) {
+	if e.bytewise {
+		less := func(a, b string) bool { return a < b }
+		if e.Options.Reverse {
+			less = func(a, b string) bool { return a > b }
+		}
+		parallelSort(lines, e.Parallel, less)
+		return
+	}
+	if e.Format == FormatText {
+		parallelSort(lines, e.Parallel, e.less)
+		return
+	}
+
+	records := make([]record, len(lines))
+	for i, line := range lines {
+		records[i] = e.newRecord(line)
+	}
+	parallelSort(records, e.Parallel, func(a, b record) bool {
+		return e.compareRecords(a, b) < 0
+	})
+	for i, r := range records {
+		lines[i] = r.line
+	}
+}
+
+// parallelSort sorts items in place. With parallel greater than one items are split
+// into parts sorted concurrently, then neighbour parts are merged pairwise concurrently.
+func parallelSort[T any](items []T, parallel int, less func(a, b T) bool) {
+	parts := parallel
+	if max := len(items) / minParallelLines; parts > max {
+		parts = max
+	}
+	if parts <= 1 {
+		sort.Sort(lessSlice[T]{items, less})
+		return
+	}
+
+	bounds := make([]int, parts+1)
+	for i := range bounds {
+		bounds[i] = len(items) * i / parts
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < parts; i++ {
+		wg.Add(1)
+		go func(part []T) {
+			defer wg.Done()
+			sort.Sort(lessSlice[T]{part, less})
+		}(items[bounds[i]:bounds[i+1]])
+	}
+	wg.Wait()
+
+	src, dst := items, make([]T, len(items))
+	for len(bounds) > 2 {
+		next := make([]int, 0, len(bounds)/2+1)
+		for i := 0; i < len(bounds)-1; i += 2 {
+			lo, mid := bounds[i], bounds[i+1]
+			next = append(next, lo)
+			if i+2 == len(bounds) {
+				copy(dst[lo:mid], src[lo:mid])
+				continue
+			}
+
+			hi := bounds[i+2]
+			wg.Add(1)
+			go func(dst, a, b []T) {
+				defer wg.Done()
+				mergeSorted(dst, a, b, less)
+			}(dst[lo:hi], src[lo:mid], src[mid:hi])
+		}
+		wg.Wait()
+
+		bounds = append(next, len(items))
+		src, dst = dst, src
+	}
+
+	if &src[0] != &items[0] {
+		copy(items, src)
+	}
+}
+
+// lessSlice sorts items with less function, unlike sort.Slice it swaps items without reflection
+type lessSlice[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func (s lessSlice[T]) Len() int           { return len(s.items) }
+func (s lessSlice[T]) Less(i, j int) bool { return s.less(s.items[i], s.items[j]) }
+func (s lessSlice[T]) Swap(i, j int)      { s.items[i], s.items[j] = s.items[j], s.items[i] }
+
+// mergeSorted merges sorted a and b into dst, items of a go first when equal
+func mergeSorted[T any](dst, a, b []T, less func(a, b T) bool) {
+	i, j := 0, 0
+	for k := range dst {
+		if j == len(b) || (i < len(a) && !less(b[j], a[i])) {
+			dst[k] = a[i]
+			i++
+		} else {
+			dst[k] = b[j]
+			j++
+		}
+	}
+}
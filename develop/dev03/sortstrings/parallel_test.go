@@ -0,0 +1,195 @@
+package sortstrings
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomLines(n int, seed int64) []string {
+	rnd := rand.New(rand.NewSource(seed))
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%x %d %s", rnd.Int63(), rnd.Intn(1000), monthNames[rnd.Intn(len(monthNames))])
+	}
+	return lines
+}
+
+var monthNames = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+func TestSortLinesParallel(t *testing.T) {
+	data := randomLines(5*minParallelLines+123, 1)
+
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+	}{
+		{
+			desc: "whole lines",
+		},
+		{
+			desc:   "numeric key, reverse",
+			sorter: Sorter{Keys: mustParseKeys("2,2nr")},
+		},
+		{
+			desc:   "month key, unique",
+			sorter: Sorter{Keys: mustParseKeys("3,3M"), Unique: true},
+		},
+	}
+	for _, tC := range testCases {
+		want, err := tC.sorter.SortLines(append([]string(nil), data...))
+		require.NoError(t, err)
+		for _, parallel := range []int{2, 3, 4, 8} {
+			t.Run(fmt.Sprintf("%s, parallel %d", tC.desc, parallel), func(t *testing.T) {
+				s := tC.sorter
+				s.Parallel = parallel
+				got, err := s.SortLines(append([]string(nil), data...))
+				require.NoError(t, err)
+				assert.Equal(t, want, got)
+			})
+		}
+	}
+}
+
+func TestSortLinesBytewise(t *testing.T) {
+	data := randomLines(minParallelLines+123, 2)
+	data = append(data, "", " b", "b", "B", "b ", "ё", "е", data[0])
+
+	for _, reverse := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reverse %t", reverse), func(t *testing.T) {
+			e, err := (&Sorter{Options: Options{Reverse: reverse}}).engine()
+			require.NoError(t, err)
+			require.True(t, e.bytewise)
+			got := append([]string(nil), data...)
+			e.sortLines(got)
+
+			// comparison of extracted whole line keys gives the same order
+			e.bytewise = false
+			want := append([]string(nil), data...)
+			e.sortLines(want)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	for _, s := range []Sorter{
+		{Keys: mustParseKeys("1")},
+		{Options: Options{FoldCase: true}},
+		{Locale: "ru"},
+		{Format: FormatCSV},
+	} {
+		e, err := s.engine()
+		require.NoError(t, err)
+		assert.False(t, e.bytewise, s)
+	}
+}
+
+func TestSortLinesParallelSmallInput(t *testing.T) {
+	s := Sorter{Parallel: 4}
+	got, err := s.SortLines([]string{"b", "c", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+}
+
+var (
+	benchOnce  sync.Once
+	benchLines []string
+)
+
+func BenchmarkSortLines(b *testing.B) {
+	benchOnce.Do(func() {
+		benchLines = randomLines(2_000_000, 1)
+	})
+
+	// baseline is the sort used before parallel sorting of keys
+	b.Run("whole lines/sort.Strings", func(b *testing.B) {
+		lines := make([]string, len(benchLines))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			copy(lines, benchLines)
+			b.StartTimer()
+			sort.Strings(lines)
+		}
+	})
+
+	// baseline of keyed sort is the column sort used before keys, it splits lines
+	// to fields and sorts them by the numeric column with sort.Sort
+	b.Run("numeric key/sort.Sort(stringTable)", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			t := stringTable{data: make([][]string, 0, len(benchLines)), column: 1, isNumeric: true}
+			for _, v := range benchLines {
+				t.data = append(t.data, strings.Fields(v))
+			}
+			sort.Sort(t)
+		}
+	})
+
+	for _, bC := range []struct {
+		desc   string
+		sorter Sorter
+	}{
+		{desc: "whole lines"},
+		{desc: "whole lines, fold case", sorter: Sorter{Options: Options{FoldCase: true}}},
+		{desc: "numeric key", sorter: Sorter{Keys: mustParseKeys("2,2n")}},
+	} {
+		for _, parallel := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("%s/parallel=%d", bC.desc, parallel), func(b *testing.B) {
+				s := bC.sorter
+				s.Parallel = parallel
+				e, err := s.engine()
+				require.NoError(b, err)
+				lines := make([]string, len(benchLines))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					copy(lines, benchLines)
+					b.StartTimer()
+					e.sortLines(lines)
+				}
+			})
+		}
+	}
+}
+
+// stringTable is the column sort used before keys, it is kept for benchmarks
+type stringTable struct {
+	data      [][]string
+	column    int
+	isNumeric bool
+}
+
+func (t stringTable) Len() int {
+	return len(t.data)
+}
+
+func (t stringTable) Less(i, j int) bool {
+	col := t.column
+	if col > len(t.data[i])-1 || col > len(t.data[j]) {
+		col = 0
+	}
+
+	if t.isNumeric {
+		i1, err := strconv.Atoi(trimNonNumber(t.data[i][col]))
+		if err != nil {
+			return t.data[i][col] < t.data[j][col]
+		}
+		j1, err := strconv.Atoi(trimNonNumber(t.data[j][col]))
+		if err != nil {
+			return t.data[i][col] < t.data[j][col]
+		}
+
+		return i1 < j1
+	}
+	return t.data[i][col] < t.data[j][col]
+}
+
+func (t stringTable) Swap(i, j int) {
+	t.data[i], t.data[j] = t.data[j], t.data[i]
+}
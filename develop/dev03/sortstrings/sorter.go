@@ -0,0 +1,265 @@
+package sortstrings
+
+import (
+	"fmt"
+	"io"
+)
+
+// Format is a format of records, structured records are compared
+// by fields instead of whitespace separated positions
+type Format int
+
+// Formats of records
+const (
+	// FormatText records are lines split into fields by blanks or Separator
+	FormatText Format = iota
+	// FormatCSV records are CSV rows, the first row of every input is a header
+	FormatCSV
+	// FormatJSONL records are JSON values, one per line
+	FormatJSONL
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSONL:
+		return "jsonl"
+	}
+	return "text"
+}
+
+// Comparator compares key values, it returns a negative number when a goes
+// before b, positive when after and zero when values are equal
+type Comparator func(a, b string) int
+
+// Options are ordering options of a key. Options of Sorter are used by keys without
+// own options and comparator. Only one of Numeric, General, Human, Month and Version may be set.
+type Options struct {
+	// Numeric compares integers, values that are not numbers are compared as strings (-n)
+	Numeric bool
+	// General compares floating point numbers like 1.5e3, inf or nan (-g)
+	General bool
+	// Human compares numbers with suffixes like 2K or 1.5G (-h)
+	Human bool
+	// Month compares month names of MonthLocale language and English ones, unknown names go first (-M)
+	Month bool
+	// Version compares numbers within text naturally, like v1.9 < v1.10 (-V)
+	Version bool
+	// Reverse reverses the result of comparison (-r)
+	Reverse bool
+	// FoldCase compares lower case letters as upper case (-f)
+	FoldCase bool
+	// Dictionary compares only blanks, letters and digits (-d)
+	Dictionary bool
+	// SkipStartBlanks ignores leading blanks of the key start field (-b)
+	SkipStartBlanks bool
+	// SkipEndBlanks ignores leading blanks of the key end field (-b)
+	SkipEndBlanks bool
+}
+
+// Position is a 1-based field and character of key start or end. Zero Char of start
+// means the first character, zero Char of end means the end of the field.
+type Position struct {
+	Field int
+	Char  int
+}
+
+// Key is a part of record used for comparison. Text records use Start and End
+// positions, zero End.Field means the end of line. Structured records use Field,
+// a CSV column name or 1-based number, or a JSON path like .user.id.
+// Table rows use column Start.Field. Comparator, when set, is used instead of
+// ordering options, Options.Reverse still applies.
+type Key struct {
+	Start      Position
+	End        Position
+	Field      string
+	Options    Options
+	Comparator Comparator
+}
+
+// ParseKey parses key definition like sort -k does: POS1[,POS2] for text,
+// where POS is F[.C][OPTS], and NAME[:OPTS] for structured formats
+func ParseKey(def string, format Format) (Key, error) {
+	if format == FormatText {
+		return parseKey(def)
+	}
+	k, err := parseFieldKey(def)
+	if err != nil {
+		return Key{}, err
+	}
+	if _, err = newFieldKey(k, format); err != nil {
+		return Key{}, err
+	}
+	return k, nil
+}
+
+// DefaultBufferSize is used when Sorter.BufferSize is zero
+const DefaultBufferSize = 256 << 20
+
+// Sorter sorts records like GNU sort, the zero value sorts lines by bytes.
+// Sorter is not changed by sorting, so it may be used concurrently.
+type Sorter struct {
+	// Keys are compared in order, records with equal keys are ordered by the whole record.
+	// Without keys the whole record is the key.
+	Keys []Key
+	// Options are used by keys without own options and for the whole record comparison
+	Options Options
+	// Unique leaves one of identical records
+	Unique bool
+	// Format is a format of records
+	Format Format
+	// Separator separates fields of text and CSV records, zero means blanks for text and comma for CSV
+	Separator rune
+	// Locale sets collation rules for text comparison, like ru or en-US. Empty locale compares code points.
+	Locale string
+	// MonthLocale sets language of month names for Month order, like ru or de_DE.UTF-8.
+	// Locale is used when it is empty. English names are recognized in every locale.
+	MonthLocale string
+	// ZeroTerminated records end with NUL, not newline
+	ZeroTerminated bool
+	// BufferSize limits memory used for records, bigger inputs are sorted in parts
+	// stored in temporary files. Zero means DefaultBufferSize.
+	BufferSize int64
+	// TempDir is a directory for temporary files, empty means os.TempDir
+	TempDir string
+	// Parallel is a number of goroutines sorting records, zero means one
+	Parallel int
+}
+
+// DisorderError is returned by Sorter.Check for the first record that is out of order
+type DisorderError struct {
+	Line int
+	Text string
+}
+
+func (e *DisorderError) Error() string {
+	return fmt.Sprintf("%d: disorder: %s", e.Line, e.Text)
+}
+
+// Validate checks that sorter options are consistent
+func (s *Sorter) Validate() error {
+	_, err := s.engine()
+	return err
+}
+
+// Sort sorts records of all readers and writes them to w. CSV header of the first input
+// is written first, headers of other inputs are skipped.
+func (s *Sorter) Sort(w io.Writer, readers ...io.Reader) error {
+	e, err := s.engine()
+	if err != nil {
+		return err
+	}
+	return e.process(readers, w, false)
+}
+
+// Merge merges records of already sorted readers into w without sorting them
+func (s *Sorter) Merge(w io.Writer, readers ...io.Reader) error {
+	e, err := s.engine()
+	if err != nil {
+		return err
+	}
+	return e.process(readers, w, true)
+}
+
+// Check checks that records read from r are sorted, *DisorderError is
+// returned for the first record out of order. With Unique equal records are out of order too.
+func (s *Sorter) Check(r io.Reader) error {
+	e, err := s.engine()
+	if err != nil {
+		return err
+	}
+	return e.check(r)
+}
+
+// SortLines sorts records in memory, with Unique identical records are left once.
+// Lines are sorted in place.
+func (s *Sorter) SortLines(lines []string) ([]string, error) {
+	e, err := s.engine()
+	if err != nil {
+		return nil, err
+	}
+	return e.sort(lines), nil
+}
+
+// SortTable sorts rows in memory by columns set by Start.Field of keys, rows
+// with equal keys are ordered by their columns. Rows are sorted in place.
+func (s *Sorter) SortTable(rows [][]string) ([][]string, error) {
+	e, err := s.engine()
+	if err != nil {
+		return nil, err
+	}
+	return e.sortTable(rows), nil
+}
+
+// engine is a validated copy of Sorter with prepared collator and structured keys
+type engine struct {
+	Sorter
+	collator *collator
+	months   months
+	fields   []fieldKey
+	// bytewise is set when whole text lines are compared as bytes, without keys,
+	// options except reverse and collation, so comparison needs no key extraction
+	bytewise bool
+}
+
+func (s *Sorter) engine() (*engine, error) {
+	e := &engine{Sorter: *s}
+	if err := e.Options.validate(); err != nil {
+		return nil, err
+	}
+	if e.Parallel < 0 {
+		return nil, fmt.Errorf("number of goroutines should not be negative")
+	}
+	if e.BufferSize < 0 {
+		return nil, fmt.Errorf("buffer size should not be negative")
+	}
+	if e.BufferSize == 0 {
+		e.BufferSize = DefaultBufferSize
+	}
+
+	switch e.Format {
+	case FormatText:
+		for _, k := range e.Keys {
+			if k.Start.Field < 1 {
+				return nil, fmt.Errorf("key start field should be positive")
+			}
+			if err := k.Options.validate(); err != nil {
+				return nil, err
+			}
+		}
+	case FormatCSV, FormatJSONL:
+		if e.ZeroTerminated {
+			return nil, fmt.Errorf("zero terminated records can't be used with %s format", e.Format)
+		}
+		for _, k := range e.Keys {
+			fk, err := newFieldKey(k, e.Format)
+			if err != nil {
+				return nil, err
+			}
+			e.fields = append(e.fields, fk)
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %d", e.Format)
+	}
+
+	if e.Locale != "" {
+		var err error
+		e.collator, err = newCollator(e.Locale)
+		if err != nil {
+			return nil, err
+		}
+	}
+	monthLocale := e.MonthLocale
+	if monthLocale == "" {
+		monthLocale = e.Locale
+	}
+	var err error
+	if e.months, err = newMonths(monthLocale); err != nil {
+		return nil, err
+	}
+	e.bytewise = e.Format == FormatText && len(e.Keys) == 0 && e.collator == nil &&
+		(e.Options == Options{} || e.Options == Options{Reverse: true})
+
+	return e, nil
+}
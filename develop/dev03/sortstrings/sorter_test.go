@@ -0,0 +1,154 @@
+package sortstrings
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// byLength orders values by their length in bytes
+func byLength(a, b string) int {
+	return len(a) - len(b)
+}
+
+func TestSortComparator(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+		data   []string
+		want   []string
+	}{
+		{
+			desc:   "whole line",
+			sorter: Sorter{Keys: []Key{{Start: Position{Field: 1}, Comparator: byLength}}},
+			data:   []string{"ccc", "b", "aa", "a"},
+			want:   []string{"a", "b", "aa", "ccc"},
+		},
+		{
+			desc: "reverse key",
+			sorter: Sorter{Keys: []Key{{
+				Start:      Position{Field: 2},
+				End:        Position{Field: 2},
+				Options:    Options{Reverse: true},
+				Comparator: byLength,
+			}}},
+			data: []string{"x a", "y bbb", "z cc"},
+			want: []string{"y bbb", "z cc", "x a"},
+		},
+		{
+			desc: "comparator with other keys",
+			sorter: Sorter{Keys: []Key{
+				{Start: Position{Field: 1}, End: Position{Field: 1}, Comparator: byLength},
+				{Start: Position{Field: 2}, Options: Options{Numeric: true}},
+			}},
+			data: []string{"bb 1", "a 10", "c 9"},
+			want: []string{"c 9", "a 10", "bb 1"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := tC.sorter.SortLines(tC.data)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestSortComparatorCSV(t *testing.T) {
+	s := Sorter{Format: FormatCSV, Keys: []Key{{Field: "name", Comparator: byLength}}}
+
+	var out bytes.Buffer
+	err := s.Sort(&out, strings.NewReader("name,age\nalice,1\nbob,2\neve,3\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "name,age\nbob,2\neve,3\nalice,1\n", out.String())
+}
+
+func TestSortTable(t *testing.T) {
+	rows := [][]string{
+		{"bob", "20", "london"},
+		{"alice", "30"},
+		{"carol", "20", "berlin"},
+		{"bob", "20", "london"},
+		{"dave", "100", "paris"},
+	}
+
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+		want   [][]string
+	}{
+		{
+			desc: "all columns",
+			want: [][]string{
+				{"alice", "30"},
+				{"bob", "20", "london"},
+				{"bob", "20", "london"},
+				{"carol", "20", "berlin"},
+				{"dave", "100", "paris"},
+			},
+		},
+		{
+			desc: "numeric column, unique",
+			sorter: Sorter{
+				Keys:   []Key{{Start: Position{Field: 2}, Options: Options{Numeric: true}}},
+				Unique: true,
+			},
+			want: [][]string{
+				{"bob", "20", "london"},
+				{"carol", "20", "berlin"},
+				{"alice", "30"},
+				{"dave", "100", "paris"},
+			},
+		},
+		{
+			desc: "missing column, reverse",
+			sorter: Sorter{
+				Keys:    []Key{{Start: Position{Field: 3}}},
+				Options: Options{Reverse: true},
+			},
+			want: [][]string{
+				{"dave", "100", "paris"},
+				{"bob", "20", "london"},
+				{"bob", "20", "london"},
+				{"carol", "20", "berlin"},
+				{"alice", "30"},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data := append([][]string(nil), rows...)
+			got, err := tC.sorter.SortTable(data)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestSorterValidate(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sorter Sorter
+	}{
+		{desc: "incompatible options", sorter: Sorter{Options: Options{Numeric: true, Month: true}}},
+		{desc: "zero key field", sorter: Sorter{Keys: []Key{{}}}},
+		{desc: "negative parallel", sorter: Sorter{Parallel: -1}},
+		{desc: "negative buffer size", sorter: Sorter{BufferSize: -1}},
+		{desc: "unknown locale", sorter: Sorter{Locale: "not a locale"}},
+		{desc: "unknown month locale", sorter: Sorter{MonthLocale: "not a locale"}},
+		{desc: "csv key without field", sorter: Sorter{Format: FormatCSV, Keys: []Key{{}}}},
+		{desc: "invalid JSON path", sorter: Sorter{Format: FormatJSONL, Keys: []Key{{Field: "ts"}}}},
+		{desc: "zero terminated csv", sorter: Sorter{Format: FormatCSV, ZeroTerminated: true}},
+		{desc: "unknown format", sorter: Sorter{Format: Format(10)}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Error(t, tC.sorter.Validate())
+		})
+	}
+
+	assert.NoError(t, (&Sorter{}).Validate())
+}
@@ -1,15 +1,18 @@
 package sortstrings
 
 import (
-	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
+// defaultBufferSize is used when -S is not set
+const defaultBufferSize = "256M"
+
 // CLI runs the go-sort command line app and returns its exit status.
 func CLI(args []string) int {
 	var app appEnv
@@ -18,6 +21,13 @@ func CLI(args []string) int {
 		return 2
 	}
 	if err = app.run(); err != nil {
+		var disorder *DisorderError
+		if errors.As(err, &disorder) {
+			if !app.checkQuiet {
+				fmt.Fprintf(os.Stderr, "go-sort: %s:%v\n", app.inputNames[0], disorder)
+			}
+			return 1
+		}
 		fmt.Fprintf(os.Stderr, "Runtime error: %v\n", err)
 		return 1
 	}
@@ -25,103 +35,183 @@ func CLI(args []string) int {
 }
 
 type appEnv struct {
-	isNumeric       bool
-	isReverse       bool
-	deleteDuplicate bool
-	column          int
-	reader          io.ReadCloser
+	sorter      Sorter
+	checkSorted bool
+	checkQuiet  bool
+	merge       bool
+	inputNames  []string
+	readers     []io.ReadCloser
+	output      string
+	writer      io.Writer
 }
 
 func (app *appEnv) fromArgs(args []string) error {
-	fl := flag.NewFlagSet("sortfile", flag.ContinueOnError)
-	fl.IntVar(&app.column, "k", 1, "sort via a column")
-	fl.BoolVar(&app.isNumeric, "n", false, "compare according to string numerical value")
-	fl.BoolVar(&app.isReverse, "r", false, "reverse the result of comparisons")
-	fl.BoolVar(&app.deleteDuplicate, "u", false, "delete duplicate strings")
+	var keyDefs stringList
+	var ignoreBlanks bool
+	opts := &app.sorter.Options
 
-	if err := fl.Parse(args); err != nil {
+	fl := flag.NewFlagSet("sortfile", flag.ContinueOnError)
+	fl.Var(&keyDefs, "k", "sort via a key `POS1[,POS2]`, POS is F[.C][OPTS], OPTS are b, d, f, g, h, M, n, r, V; "+
+		"for csv and jsonl formats key is NAME[:OPTS], NAME is a column name or number or a JSON path like .user.id; can be repeated")
+	format := fl.String("format", "text", "input `FORMAT`: text, csv with header row or jsonl")
+	separator := fl.String("t", "", "use `SEP` instead of non-blank to blank transition as field separator")
+	fl.BoolVar(&opts.Numeric, "n", false, "compare according to string numerical value")
+	fl.BoolVar(&opts.General, "g", false, "compare according to general numerical value like 1.5e3")
+	fl.BoolVar(&opts.Human, "h", false, "compare human readable numbers like 2K or 1.5G")
+	fl.BoolVar(&opts.Month, "M", false, "compare month names of LC_TIME or --locale language and English ones, unknown < JAN")
+	fl.BoolVar(&opts.Version, "V", false, "natural sort of version numbers within text")
+	fl.BoolVar(&opts.FoldCase, "f", false, "fold lower case to upper case characters")
+	fl.BoolVar(&opts.Dictionary, "d", false, "consider only blanks, letters and digits")
+	fl.StringVar(&app.sorter.Locale, "locale", "", "compare text according to `LOCALE` collation rules, like ru or en-US")
+	fl.BoolVar(&opts.Reverse, "r", false, "reverse the result of comparisons")
+	fl.BoolVar(&ignoreBlanks, "b", false, "ignore leading blanks")
+	fl.BoolVar(&app.sorter.Unique, "u", false, "delete duplicate strings")
+	fl.BoolVar(&app.checkSorted, "c", false, "check for sorted input, report the first disorder line")
+	fl.BoolVar(&app.checkQuiet, "C", false, "like -c, but do not report the first disorder line")
+	size := fl.String("S", defaultBufferSize, "use `SIZE` for main memory buffer, suffixes b, K, M, G, T are supported, K is default")
+	fl.StringVar(&app.sorter.TempDir, "T", "", "use `DIR` for temporary files, not $TMPDIR or /tmp")
+	fl.IntVar(&app.sorter.Parallel, "parallel", 1, "sort using `N` goroutines")
+	fl.BoolVar(&app.merge, "m", false, "merge already sorted files, do not sort")
+	fl.StringVar(&app.output, "o", "", "write result to `FILE` instead of standard output, it may be one of input files")
+	fl.BoolVar(&app.sorter.ZeroTerminated, "z", false, "line delimiter is NUL, not newline")
+
+	if err := fl.Parse(splitShortFlags(args)); err != nil {
 		fl.Usage()
 		return err
 	}
+	opts.SkipStartBlanks = ignoreBlanks
+	opts.SkipEndBlanks = ignoreBlanks
 
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		app.reader = os.Stdin
-		return nil
+	var err error
+	app.sorter.Format, err = parseFormat(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid options: %v\n", err)
+		return err
 	}
-
-	file, err := os.Open(fl.Arg(0))
+	for _, def := range keyDefs {
+		var k Key
+		k, err = ParseKey(def, app.sorter.Format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid key: %v\n", err)
+			return err
+		}
+		app.sorter.Keys = append(app.sorter.Keys, k)
+	}
+	if app.sorter.Parallel < 1 {
+		err = fmt.Errorf("number of goroutines should be positive")
+		fmt.Fprintf(os.Stderr, "invalid parallel %d: %v\n", app.sorter.Parallel, err)
+		return err
+	}
+	app.sorter.BufferSize, err = parseSize(*size)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "can't open file %s: %v\n", fl.Arg(0), err)
+		fmt.Fprintf(os.Stderr, "invalid buffer size %s: %v\n", *size, err)
 		return err
 	}
-	app.reader = file
-
-	return nil
-}
-
-func (app *appEnv) run() error {
-	defer app.reader.Close()
-	data := make([]string, 0)
-
-	scanner := bufio.NewScanner(app.reader)
-	for scanner.Scan() {
-		data = append(data, scanner.Text())
+	if utf8.RuneCountInString(*separator) > 1 {
+		err = fmt.Errorf("multi-character separator")
+		fmt.Fprintf(os.Stderr, "invalid separator %s: %v\n", *separator, err)
+		return err
+	}
+	if app.sorter.Locale == "" {
+		app.sorter.MonthLocale = timeLocale()
 	}
-	if err := scanner.Err(); err != nil {
+	app.sorter.Separator, _ = utf8.DecodeRuneInString(*separator)
+	if app.sorter.Separator == utf8.RuneError {
+		app.sorter.Separator = 0
+	}
+	if err = app.sorter.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid options: %v\n", err)
 		return err
 	}
+	app.writer = os.Stdout
 
-	if app.column == 1 && !app.isNumeric {
-		data = app.sort(data)
-		writeToOutput(data)
-		return nil
+	app.inputNames = fl.Args()
+	if len(app.inputNames) == 0 {
+		app.inputNames = []string{"-"}
+	}
+	if (app.checkSorted || app.checkQuiet) && len(app.inputNames) > 1 {
+		err = fmt.Errorf("extra operand %s", app.inputNames[1])
+		fmt.Fprintf(os.Stderr, "can't check: %v\n", err)
+		return err
 	}
 
-	data = app.sortColumns(data)
-	writeToOutput(data)
+	for _, name := range app.inputNames {
+		if name == "-" {
+			app.readers = append(app.readers, io.NopCloser(os.Stdin))
+			continue
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't open file %s: %v\n", name, err)
+			app.closeReaders()
+			return err
+		}
+		app.readers = append(app.readers, file)
+	}
 
 	return nil
 }
 
-func (app *appEnv) sort(data []string) []string {
-	if app.isReverse {
-		sort.Sort(sort.Reverse(sort.StringSlice(data)))
-	} else {
-		sort.Strings(data)
-	}
+func (app *appEnv) run() error {
+	defer app.closeReaders()
 
-	if app.deleteDuplicate {
-		data = delDuplicate(data)
+	if app.checkSorted || app.checkQuiet {
+		return app.sorter.Check(app.readers[0])
 	}
 
-	return data
-}
-
-func (app *appEnv) sortColumns(data []string) []string {
-	t := stringTable{
-		data:      make([][]string, 0, len(data)),
-		column:    app.column - 1,
-		isNumeric: app.isNumeric,
+	readers := make([]io.Reader, 0, len(app.readers))
+	for _, r := range app.readers {
+		readers = append(readers, r)
 	}
-
-	for _, v := range data {
-		t.data = append(t.data, strings.Fields(v))
+	process := app.sorter.Sort
+	if app.merge {
+		process = app.sorter.Merge
 	}
 
-	if app.isReverse {
-		sort.Sort(sort.Reverse(t))
-	} else {
-		sort.Sort(t)
+	if app.output == "" {
+		return process(app.writer, readers...)
 	}
+	// inputs are read before output is renamed, so output may be one of them
+	return writeFileAtomic(app.output, func(w io.Writer) error {
+		return process(w, readers...)
+	})
+}
 
-	for i, v := range t.data {
-		data[i] = strings.Join(v, " ")
+func (app *appEnv) closeReaders() {
+	for _, r := range app.readers {
+		r.Close()
 	}
+}
 
-	if app.deleteDuplicate {
-		data = delDuplicate(data)
+// valueFlags are flags that may be written together with their values like -k2,2n
+const valueFlags = "ktSTo"
+
+// splitShortFlags splits flags written GNU style together with their values
+// (-k2,2n, -t:) into a flag and a value
+func splitShortFlags(args []string) []string {
+	res := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(res, args[i:]...)
+		}
+		if len(arg) > 2 && arg[0] == '-' && arg[2] != '=' && strings.IndexByte(valueFlags, arg[1]) >= 0 {
+			res = append(res, arg[:2], arg[2:])
+			continue
+		}
+		res = append(res, arg)
 	}
+	return res
+}
 
-	return data
+// stringList is a flag.Value collecting repeated flags
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
 }
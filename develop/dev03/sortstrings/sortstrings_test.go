@@ -4,18 +4,19 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSort(t *testing.T) {
 	testCases := []struct {
-		desc string
-		app  appEnv
-		data []string
-		want []string
+		desc   string
+		sorter Sorter
+		data   []string
+		want   []string
 	}{
 		{
-			desc: "normal",
-			app:  appEnv{},
+			desc:   "normal",
+			sorter: Sorter{},
 			data: []string{
 				"Standing on one's head at job interviews forms a lasting impression.",
 				"The chic gangster liked to start the day with a pink scarf.",
@@ -32,8 +33,8 @@ func TestSort(t *testing.T) {
 			},
 		},
 		{
-			desc: "not numeric order",
-			app:  appEnv{},
+			desc:   "not numeric order",
+			sorter: Sorter{},
 			data: []string{
 				"1",
 				"5",
@@ -55,8 +56,8 @@ func TestSort(t *testing.T) {
 		},
 		{
 			desc: "reverse order",
-			app: appEnv{
-				isReverse: true,
+			sorter: Sorter{
+				Options: Options{Reverse: true},
 			},
 			data: []string{
 				"1",
@@ -79,8 +80,8 @@ func TestSort(t *testing.T) {
 		},
 		{
 			desc: "delete duplicate",
-			app: appEnv{
-				deleteDuplicate: true,
+			sorter: Sorter{
+				Unique: true,
 			},
 			data: []string{
 				"1",
@@ -108,7 +109,8 @@ func TestSort(t *testing.T) {
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
-			got := tC.app.sort(tC.data)
+			got, err := tC.sorter.SortLines(tC.data)
+			require.NoError(t, err)
 			assert.Equal(t, tC.want, got)
 		})
 	}
@@ -116,15 +118,15 @@ func TestSort(t *testing.T) {
 
 func TestSortColumns(t *testing.T) {
 	testCases := []struct {
-		desc string
-		app  appEnv
-		data []string
-		want []string
+		desc   string
+		sorter Sorter
+		data   []string
+		want   []string
 	}{
 		{
 			desc: "by 2nd column",
-			app: appEnv{
-				column: 2,
+			sorter: Sorter{
+				Keys: []Key{{Start: Position{Field: 2}}},
 			},
 			data: []string{
 				"Standing on one's head at job interviews forms a lasting impression.",
@@ -143,8 +145,8 @@ func TestSortColumns(t *testing.T) {
 		},
 		{
 			desc: "by column out of range",
-			app: appEnv{
-				column: 200,
+			sorter: Sorter{
+				Keys: []Key{{Start: Position{Field: 200}}},
 			},
 			data: []string{
 				"Standing on one's head at job interviews forms a lasting impression.",
@@ -163,9 +165,9 @@ func TestSortColumns(t *testing.T) {
 		},
 		{
 			desc: "numbers numeric order",
-			app: appEnv{
-				column:    1,
-				isNumeric: true,
+			sorter: Sorter{
+				Keys:    []Key{{Start: Position{Field: 1}}},
+				Options: Options{Numeric: true},
 			},
 			data: []string{
 				"5",
@@ -188,9 +190,9 @@ func TestSortColumns(t *testing.T) {
 		},
 		{
 			desc: "by 2nd column, in reverse",
-			app: appEnv{
-				column:    2,
-				isReverse: true,
+			sorter: Sorter{
+				Keys:    []Key{{Start: Position{Field: 2}}},
+				Options: Options{Reverse: true},
 			},
 			data: []string{
 				"Standing on one's head at job interviews forms a lasting impression.",
@@ -209,9 +211,9 @@ func TestSortColumns(t *testing.T) {
 		},
 		{
 			desc: "delete duplicate",
-			app: appEnv{
-				column:          1,
-				deleteDuplicate: true,
+			sorter: Sorter{
+				Keys:   []Key{{Start: Position{Field: 1}}},
+				Unique: true,
 			},
 			data: []string{
 				"1",
@@ -238,9 +240,9 @@ func TestSortColumns(t *testing.T) {
 		},
 		{
 			desc: "numeric sort, but column starts with letter",
-			app: appEnv{
-				column:    1,
-				isNumeric: true,
+			sorter: Sorter{
+				Keys:    []Key{{Start: Position{Field: 1}}},
+				Options: Options{Numeric: true},
 			},
 			data: []string{
 				"d1",
@@ -264,7 +266,8 @@ func TestSortColumns(t *testing.T) {
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
-			got := tC.app.sortColumns(tC.data)
+			got, err := tC.sorter.SortLines(tC.data)
+			require.NoError(t, err)
 			assert.Equal(t, tC.want, got)
 		})
 	}
@@ -1,44 +1,53 @@
 package sortstrings
 
-import (
-	"strconv"
-)
-
-// stringTable represents slice of slices of strings
-type stringTable struct {
-	data      [][]string
-	column    int
-	isNumeric bool
-}
-
-func (t stringTable) Len() int {
-	return len(t.data)
+// tableRow is a row with values of its keys
+type tableRow struct {
+	cells  []string
+	values []string
 }
 
-func (t stringTable) Less(i, j int) bool {
-	col := t.column
-	if col > len(t.data[i])-1 || col > len(t.data[j]) {
-		col = 0
+// sortTable sorts rows by key columns, rows with equal keys are compared column
+// by column, so only identical rows compare equal
+func (e *engine) sortTable(rows [][]string) [][]string {
+	records := make([]tableRow, len(rows))
+	for i, cells := range rows {
+		records[i] = tableRow{cells: cells, values: make([]string, len(e.Keys))}
+		for j, k := range e.Keys {
+			if col := k.Start.Field - 1; col < len(cells) {
+				records[i].values[j] = cells[col]
+			}
+		}
 	}
 
-	if t.isNumeric {
-		n1 := trimNonNumber(t.data[i][col])
-		n2 := trimNonNumber(t.data[j][col])
+	parallelSort(records, e.Parallel, func(a, b tableRow) bool {
+		return e.compareRows(a, b) < 0
+	})
 
-		i1, err := strconv.Atoi(n1)
-		if err != nil {
-			return (t.data[i][col] < t.data[j][col])
-		}
-		j1, err := strconv.Atoi(n2)
-		if err != nil {
-			return (t.data[i][col] < t.data[j][col])
+	res := rows[:0]
+	for i, r := range records {
+		if e.Unique && i > 0 && e.compareRows(records[i-1], r) == 0 {
+			continue
 		}
-
-		return i1 < j1
+		res = append(res, r.cells)
 	}
-	return (t.data[i][col] < t.data[j][col])
+	return res
 }
 
-func (t stringTable) Swap(i, j int) {
-	t.data[i], t.data[j] = t.data[j], t.data[i]
+func (e *engine) compareRows(a, b tableRow) int {
+	for i, k := range e.Keys {
+		if c := e.compareKey(k, a.values[i], b.values[i]); c != 0 {
+			return c
+		}
+	}
+
+	for i := 0; i < len(a.cells) && i < len(b.cells); i++ {
+		if c := e.compareLines(a.cells[i], b.cells[i]); c != 0 {
+			return c
+		}
+	}
+	c := len(a.cells) - len(b.cells)
+	if e.Options.Reverse {
+		return -c
+	}
+	return c
 }
@@ -2,34 +2,97 @@ package sortstrings
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode"
 )
 
+// delDuplicate deletes adjacent equal strings of sorted data
 func delDuplicate(data []string) []string {
-	exists := make(map[string]struct{}, len(data))
-	res := make([]string, 0, len(data))
-	for _, v := range data {
-		if _, ok := exists[v]; ok {
+	res := data[:0]
+	for i, v := range data {
+		if i > 0 && v == data[i-1] {
 			continue
 		}
 		res = append(res, v)
-		exists[v] = struct{}{}
 	}
 
 	return res
 }
 
-func writeToOutput(data []string) {
-	for _, v := range data {
-		fmt.Fprintf(os.Stdout, "%s\n", v)
-	}
-}
-
 // trimNonNumber deletes non number runes from the end of the string
 func trimNonNumber(str string) string {
 	return strings.TrimRightFunc(str, func(r rune) bool {
 		return !unicode.IsNumber(r)
 	})
 }
+
+// parseSize parses buffer size like GNU sort does: number with optional
+// suffix b (bytes), K, M, G or T, number without suffix is in kilobytes
+func parseSize(s string) (int64, error) {
+	multiplier := int64(1 << 10)
+	if s != "" {
+		known := true
+		switch s[len(s)-1] {
+		case 'b':
+			multiplier = 1
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		case 't', 'T':
+			multiplier = 1 << 40
+		default:
+			known = false
+		}
+		if known {
+			s = s[:len(s)-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("size should be positive")
+	}
+	if n > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("size is too big")
+	}
+
+	return n * multiplier, nil
+}
+
+// writeFileAtomic writes file with write function to a temporary file in the same
+// directory and renames it, so the file is left untouched when write fails
+func writeFileAtomic(name string, write func(w io.Writer) error) error {
+	mode := os.FileMode(0o644)
+	if stat, err := os.Stat(name); err == nil {
+		mode = stat.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = write(f)
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,76 @@
+package sortstrings
+
+import "strings"
+
+// compareVersions compares strings like GNU sort -V: digit sequences are compared
+// as numbers and other parts character by character, letters go before other characters
+// and tilde goes before anything, even the end of string, so 1.0~rc1 < 1.0
+func compareVersions(a, b string) int {
+	for a != "" || b != "" {
+		var c int
+		c, a, b = compareNonDigits(a, b)
+		if c != 0 {
+			return c
+		}
+
+		c, a, b = compareDigits(a, b)
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareNonDigits compares leading non digit parts, it returns the rest of strings
+func compareNonDigits(a, b string) (int, string, string) {
+	for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+		o1, o2 := versionOrder(a), versionOrder(b)
+		if o1 != o2 {
+			return o1 - o2, a, b
+		}
+		a, b = a[1:], b[1:]
+	}
+	return 0, a, b
+}
+
+// compareDigits compares leading digit sequences as numbers, it returns the rest of strings
+func compareDigits(a, b string) (int, string, string) {
+	i, j := digitsEnd(a), digitsEnd(b)
+	n1, n2 := strings.TrimLeft(a[:i], "0"), strings.TrimLeft(b[:j], "0")
+
+	c := len(n1) - len(n2)
+	if c == 0 {
+		c = strings.Compare(n1, n2)
+	}
+	return c, a[i:], b[j:]
+}
+
+// versionOrder returns weight of the first character of non digit part,
+// digit or end of the part weighs zero
+func versionOrder(s string) int {
+	switch {
+	case s == "" || isDigit(s[0]):
+		return 0
+	case s[0] == '~':
+		return -1
+	case isLetter(s[0]):
+		return int(s[0])
+	}
+	return int(s[0]) + 256
+}
+
+func digitsEnd(s string) int {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,145 @@
+package grep
+
+// acEdge is a transition of Aho-Corasick automaton by byte b
+type acEdge struct {
+	b  byte
+	to int32
+}
+
+// acNode is a state of Aho-Corasick automaton. Depth is the length of its prefix,
+// end is set when the prefix is a pattern, dict is the nearest state by failure
+// links that ends a pattern.
+type acNode struct {
+	edges []acEdge
+	fail  int32
+	dict  int32
+	depth int32
+	end   bool
+}
+
+// ahoCorasick finds any of many fixed strings in a single pass over line.
+// States keep sparse edges, only the root has a dense table, as most of
+// transitions after a mismatch go through it. With foldCase patterns and
+// lines are compared after Unicode case folding.
+type ahoCorasick struct {
+	nodes    []acNode
+	root     [256]int32
+	empty    bool
+	foldCase bool
+}
+
+func newAhoCorasick(patterns []string, foldCase bool) *ahoCorasick {
+	ac := &ahoCorasick{nodes: []acNode{{}}, foldCase: foldCase}
+	for _, p := range patterns {
+		if foldCase {
+			p, _ = foldString(p)
+		}
+		if p == "" {
+			ac.empty = true
+			continue
+		}
+		var state int32
+		for i := 0; i < len(p); i++ {
+			next := ac.child(state, p[i])
+			if next < 0 {
+				next = int32(len(ac.nodes))
+				ac.nodes = append(ac.nodes, acNode{depth: int32(i + 1)})
+				ac.nodes[state].edges = append(ac.nodes[state].edges, acEdge{b: p[i], to: next})
+			}
+			state = next
+		}
+		ac.nodes[state].end = true
+	}
+
+	// failure links are set in breadth-first order, so links of shorter prefixes are ready
+	for b := range ac.root {
+		ac.root[b] = ac.child(0, byte(b))
+		if ac.root[b] < 0 {
+			ac.root[b] = 0
+		}
+	}
+	queue := make([]int32, 0, len(ac.nodes))
+	for _, e := range ac.nodes[0].edges {
+		queue = append(queue, e.to)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, e := range ac.nodes[state].edges {
+			fail := ac.next(ac.nodes[state].fail, e.b)
+			ac.nodes[e.to].fail = fail
+			ac.nodes[e.to].dict = ac.nodes[fail].dict
+			if ac.nodes[fail].end {
+				ac.nodes[e.to].dict = fail
+			}
+			queue = append(queue, e.to)
+		}
+	}
+	return ac
+}
+
+// child returns state reached from state by b, or -1 when there is no such edge
+func (ac *ahoCorasick) child(state int32, b byte) int32 {
+	for _, e := range ac.nodes[state].edges {
+		if e.b == b {
+			return e.to
+		}
+	}
+	return -1
+}
+
+// next returns state reached from state by b, following failure links on mismatch
+func (ac *ahoCorasick) next(state int32, b byte) int32 {
+	for state != 0 {
+		if next := ac.child(state, b); next >= 0 {
+			return next
+		}
+		state = ac.nodes[state].fail
+	}
+	return ac.root[b]
+}
+
+func (ac *ahoCorasick) match(line string) bool {
+	if ac.empty {
+		return true
+	}
+	return ac.find(line, func(start, end int) bool {
+		return true
+	})
+}
+
+func (ac *ahoCorasick) matches(line string, dst [][2]int) [][2]int {
+	ac.find(line, func(start, end int) bool {
+		dst = append(dst, [2]int{start, end})
+		return false
+	})
+	return dst
+}
+
+// find calls fn with byte offsets of every non-empty occurrence of patterns in line,
+// ordered by their end, until fn returns true. It reports whether fn returned true.
+func (ac *ahoCorasick) find(line string, fn func(start, end int) bool) bool {
+	text, offsets := line, []int(nil)
+	if ac.foldCase {
+		text, offsets = foldString(line)
+	}
+
+	var state int32
+	for i := 0; i < len(text); i++ {
+		state = ac.next(state, text[i])
+		out := state
+		if !ac.nodes[out].end {
+			out = ac.nodes[out].dict
+		}
+		for ; out != 0; out = ac.nodes[out].dict {
+			start, end := i+1-int(ac.nodes[out].depth), i+1
+			if offsets != nil {
+				start, end = offsets[start], offsets[end]
+			}
+			if fn(start, end) {
+				return true
+			}
+		}
+	}
+	return false
+}
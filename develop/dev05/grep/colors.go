@@ -0,0 +1,141 @@
+package grep
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultColors are GNU grep defaults of GREP_COLORS
+const defaultColors = "ms=01;31:mc=01;31:sl=:cx=:fn=35:ln=32:bn=32:se=36"
+
+// colors are SGR sequences of output parts, empty sequence leaves part uncolored
+type colors struct {
+	selectedMatch string
+	contextMatch  string
+	selectedLine  string
+	contextLine   string
+	fileName      string
+	lineNum       string
+	byteOffset    string
+	separator     string
+	reverse       bool
+	noErase       bool
+}
+
+// colorFlag is --color[=WHEN] value, it may be used without WHEN like a boolean flag
+type colorFlag string
+
+func (f *colorFlag) String() string {
+	return string(*f)
+}
+
+func (f *colorFlag) Set(s string) error {
+	switch s {
+	case "true", "auto", "tty", "if-tty":
+		*f = "auto"
+	case "always", "yes", "force":
+		*f = "always"
+	case "never", "no", "none", "false":
+		*f = "never"
+	default:
+		return fmt.Errorf("invalid color %q, use auto, always or never", s)
+	}
+	return nil
+}
+
+func (f *colorFlag) IsBoolFlag() bool {
+	return true
+}
+
+// enabled reports whether output should be colored, auto colors only terminals
+func (f colorFlag) enabled() bool {
+	switch f {
+	case "always":
+		return true
+	case "auto":
+		return isTerminal(os.Stdout) && os.Getenv("TERM") != "dumb"
+	}
+	return false
+}
+
+// parseColors parses GREP_COLORS like ms=01;31:fn=35:ne over defaults, deprecated
+// GREP_COLOR sets color of matches. Invalid capabilities are ignored.
+func parseColors(grepColors, grepColor string) *colors {
+	c := &colors{}
+	c.set(defaultColors)
+	if grepColor != "" && isSGR(grepColor) {
+		c.selectedMatch = grepColor
+		c.contextMatch = grepColor
+	}
+	c.set(grepColors)
+	return c
+}
+
+func (c *colors) set(caps string) {
+	for _, capability := range strings.Split(caps, ":") {
+		name, value, hasValue := strings.Cut(capability, "=")
+		if hasValue && !isSGR(value) {
+			continue
+		}
+		switch name {
+		case "mt":
+			c.selectedMatch = value
+			c.contextMatch = value
+		case "ms":
+			c.selectedMatch = value
+		case "mc":
+			c.contextMatch = value
+		case "sl":
+			c.selectedLine = value
+		case "cx":
+			c.contextLine = value
+		case "fn":
+			c.fileName = value
+		case "ln":
+			c.lineNum = value
+		case "bn":
+			c.byteOffset = value
+		case "se":
+			c.separator = value
+		case "rv":
+			c.reverse = true
+		case "ne":
+			c.noErase = true
+		}
+	}
+}
+
+// isSGR reports whether s is a list of SGR parameters like 01;31
+func isSGR(s string) bool {
+	return strings.Trim(s, "0123456789;") == ""
+}
+
+// start returns sequence that starts coloring with sgr, it erases to the end of line
+// to color the line background too, unless ne capability is set
+func (c *colors) start(sgr string) string {
+	if c.noErase {
+		return "\x1b[" + sgr + "m"
+	}
+	return "\x1b[" + sgr + "m\x1b[K"
+}
+
+// end returns sequence that resets colors
+func (c *colors) end() string {
+	if c.noErase {
+		return "\x1b[m"
+	}
+	return "\x1b[m\x1b[K"
+}
+
+// writeColored writes s colored with sgr, s is written as is when colors are off
+func (c *colors) writeColored(w *bufio.Writer, sgr, s string) {
+	if c == nil || sgr == "" {
+		w.WriteString(s)
+		return
+	}
+	w.WriteString(c.start(sgr))
+	w.WriteString(s)
+	w.WriteString(c.end())
+}
@@ -0,0 +1,155 @@
+package grep
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFile is the name of files with ignore rules
+const ignoreFile = ".gitignore"
+
+// ignoreRule is a compiled .gitignore pattern
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList is rules of .gitignore in base directory, rules of parent directories
+// have lower priority
+type ignoreList struct {
+	base   string
+	rules  []ignoreRule
+	parent *ignoreList
+}
+
+// loadIgnore reads .gitignore of dir, parent is returned when dir has no rules
+func loadIgnore(dir string, parent *ignoreList) (*ignoreList, error) {
+	file, err := os.Open(filepath.Join(dir, ignoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return parent, nil
+	}
+	if err != nil {
+		return parent, err
+	}
+	defer file.Close()
+
+	list := &ignoreList{base: dir, parent: parent}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			list.rules = append(list.rules, rule)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return parent, err
+	}
+	if len(list.rules) == 0 {
+		return parent, nil
+	}
+	return list, nil
+}
+
+// ignored reports whether path is ignored, the last matching rule of the nearest
+// .gitignore decides
+func (l *ignoreList) ignored(path string, isDir bool) bool {
+	for ; l != nil; l = l.parent {
+		rel, err := filepath.Rel(l.base, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for i := len(l.rules) - 1; i >= 0; i-- {
+			rule := l.rules[i]
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(rel) {
+				return !rule.negate
+			}
+		}
+	}
+	return false
+}
+
+// parseIgnoreRule parses .gitignore line, ok is false for blank lines,
+// comments and invalid patterns
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	var rule ignoreRule
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || line[0] == '#' {
+		return rule, false
+	}
+	if line[0] == '!' {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule, false
+	}
+
+	// pattern with a slash is relative to .gitignore directory, otherwise it matches at any level
+	prefix := "^(?:.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = "^"
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	var err error
+	rule.re, err = regexp.Compile(prefix + globToRegexp(line) + "$")
+	if err != nil {
+		return rule, false
+	}
+	return rule, true
+}
+
+// globToRegexp translates gitignore glob with ** to regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end <= 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package grep
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	testCases := []struct {
+		glob string
+		want string
+	}{
+		{glob: "*.go", want: `[^/]*\.go`},
+		{glob: "a?c", want: `a[^/]c`},
+		{glob: "**/tmp", want: `(?:.*/)?tmp`},
+		{glob: "a/**", want: `a/.*`},
+		{glob: "a/**/b", want: `a/(?:.*/)?b`},
+		{glob: "[a-c]x", want: `[a-c]x`},
+		{glob: "[!a-c]x", want: `[^a-c]x`},
+		{glob: "[abc", want: `\[abc`},
+		{glob: `\*x\`, want: `\*x\\`},
+		{glob: "a+b(c)", want: `a\+b\(c\)`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.glob, func(t *testing.T) {
+			assert.Equal(t, tC.want, globToRegexp(tC.glob))
+		})
+	}
+}
+
+func TestParseIgnoreRule(t *testing.T) {
+	testCases := []struct {
+		line    string
+		ok      bool
+		negate  bool
+		dirOnly bool
+		re      string
+	}{
+		{line: ""},
+		{line: "   "},
+		{line: "# comment"},
+		{line: "!"},
+		{line: "/"},
+		{line: "*.log", ok: true, re: `^(?:.*/)?[^/]*\.log$`},
+		{line: "*.log \r", ok: true, re: `^(?:.*/)?[^/]*\.log$`},
+		{line: "!keep.log", ok: true, negate: true, re: `^(?:.*/)?keep\.log$`},
+		{line: "build/", ok: true, dirOnly: true, re: `^(?:.*/)?build$`},
+		{line: "/build/", ok: true, dirOnly: true, re: `^build$`},
+		{line: "docs/*.md", ok: true, re: `^docs/[^/]*\.md$`},
+		{line: `\#notes`, ok: true, re: `^(?:.*/)?#notes$`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.line, func(t *testing.T) {
+			rule, ok := parseIgnoreRule(tC.line)
+			require.Equal(t, tC.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tC.negate, rule.negate)
+			assert.Equal(t, tC.dirOnly, rule.dirOnly)
+			assert.Equal(t, tC.re, rule.re.String())
+		})
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	newList := func(base string, parent *ignoreList, lines ...string) *ignoreList {
+		list := &ignoreList{base: base, parent: parent}
+		for _, line := range lines {
+			rule, ok := parseIgnoreRule(line)
+			require.True(t, ok, line)
+			list.rules = append(list.rules, rule)
+		}
+		return list
+	}
+	root := filepath.FromSlash("/repo")
+	rootList := newList(root, nil, "*.log", "/build/", "docs/*.md", "**/tmp", "cache/", "secret")
+	subList := newList(filepath.Join(root, "sub"), rootList, "*.txt", "!keep.log")
+
+	testCases := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "a.go"},
+		{path: "a.log", want: true},
+		{path: "sub/x.log", want: true},
+		{path: "sub/keep.log"},
+		{path: "keep.log", want: true},
+		{path: "build", isDir: true, want: true},
+		{path: "build"},
+		{path: "sub/build", isDir: true},
+		{path: "docs/a.md", want: true},
+		{path: "docs/x/a.md"},
+		{path: "sub/docs/a.md"},
+		{path: "tmp", want: true},
+		{path: "a/b/tmp", isDir: true, want: true},
+		{path: "cache", isDir: true, want: true},
+		{path: "cache"},
+		{path: "sub/a.txt", want: true},
+		{path: "a.txt"},
+		{path: "sub/secret", want: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.path, func(t *testing.T) {
+			path := filepath.Join(root, filepath.FromSlash(tC.path))
+			// files of sub directory are checked with its own rules first
+			list := rootList
+			if strings.HasPrefix(tC.path, "sub/") {
+				list = subList
+			}
+			assert.Equal(t, tC.want, list.ignored(path, tC.isDir))
+		})
+	}
+
+	var none *ignoreList
+	assert.False(t, none.ignored(filepath.Join(root, "a.log"), false))
+}
@@ -2,11 +2,16 @@ package grep
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"regexp"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 func CLI(args []string) int {
@@ -23,18 +28,47 @@ func CLI(args []string) int {
 	return 0
 }
 
+// stdinName is the input name of standard input
+const stdinName = "-"
+
+// binaryPeek is the maximum number of first bytes checked for NUL to detect binary input
+const binaryPeek = 4096
+
+// errSkipped is returned when some inputs could not be searched
+var errSkipped = errors.New("some inputs could not be searched")
+
 type appEnv struct {
 	after        int
 	before       int
 	context      int
-	count        int
+	maxCount     int
+	count        bool
+	onlyMatching bool
+	byteOffset   bool
+	column       bool
+	color        colorFlag
+	colors       *colors
 	ignoreCase   bool
 	invert       bool
 	isFixed      bool
 	printLineNum bool
-	reader       io.ReadCloser
-	input        []string
-	pattern      string
+	recursive    bool
+	dereference  bool
+	includes     stringList
+	excludes     stringList
+	excludeDirs  stringList
+	withFilename bool
+	noFilename   bool
+	filesWith    bool
+	filesWithout bool
+	binaryText   bool
+	lineRegexp   bool
+	wordRegexp   bool
+	patterns     stringList
+	patternFiles stringList
+	inputs       []string
+	writer       *bufio.Writer
+	matcher      matcher
 }
 
 func (app *appEnv) fromArgs(args []string) error {
@@ -42,13 +76,36 @@ func (app *appEnv) fromArgs(args []string) error {
 	fl.IntVar(&app.after, "A", 0, "Print NUM lines of trailing context after matching lines.")
 	fl.IntVar(&app.before, "B", 0, "Print NUM lines of leading context before matching lines.")
 	fl.IntVar(&app.context, "C", 0, "Print NUM lines of output context.")
-	fl.IntVar(&app.count, "c", -1, "Suppress normal output; instead print a count of matching lines for each input file.  With the -v, count non-matching lines.")
+	fl.BoolVar(&app.count, "c", false, "Suppress normal output; instead print a count of matching lines for each input file.  With the -v, count non-matching lines.")
+	fl.IntVar(&app.maxCount, "m", -1, "Stop reading a file after NUM matching lines, trailing context after the last one is printed. Negative NUM means no limit.")
+	fl.BoolVar(&app.onlyMatching, "o", false, "Print only the matched (non-empty) parts of a matching line, with each such part on a separate output line. Context is not printed.")
+	fl.BoolVar(&app.byteOffset, "b", false, "Print the 0-based byte offset within the input file before each line of output. With -o, print the offset of the matching part itself.")
+	fl.BoolVar(&app.column, "column", false, "Print the 1-based byte column of the first match before each selected line. With -o, print the column of each matching part.")
+	app.color = "never"
+	fl.Var(&app.color, "color", "Surround matches, file names, line numbers, byte offsets and separators with escape sequences to display them in color on the terminal. "+
+		"`WHEN` is never, always or auto, --color alone means auto. Colors are taken from GREP_COLORS environment variable.")
 	fl.BoolVar(&app.ignoreCase, "i", false, " Ignore case distinctions in patterns and input data, so that characters that differ only in case match each other.")
 	fl.BoolVar(&app.invert, "v", false, " Invert the sense of matching, to select non-matching lines.")
 	fl.BoolVar(&app.isFixed, "F", false, " Interpret PATTERNS as fixed strings, not regular expressions.")
 	fl.BoolVar(&app.printLineNum, "n", false, "Prefix each line of output with the 1-based line number within its input file.")
+	fl.BoolVar(&app.recursive, "r", false, "Read all files under each directory, recursively, skipping symbolic links met during recursion. "+
+		"Files and directories ignored by .gitignore and .git directories are skipped. Without FILE the working directory is searched.")
+	fl.BoolVar(&app.dereference, "R", false, "Read all files under each directory, recursively. Follow all symbolic links, unlike -r.")
+	fl.Var(&app.includes, "include", "Search only files whose base name matches `GLOB`. Can be repeated.")
+	fl.Var(&app.excludes, "exclude", "Skip files whose base name matches `GLOB`. Can be repeated.")
+	fl.Var(&app.excludeDirs, "exclude-dir", "Skip directories whose base name matches `GLOB` during recursive search. Can be repeated.")
+	fl.BoolVar(&app.withFilename, "H", false, "Print the file name for each match. This is the default when there is more than one file to search.")
+	fl.BoolVar(&app.noFilename, "h", false, "Suppress the prefixing of file names on output.")
+	fl.BoolVar(&app.filesWith, "l", false, "Suppress normal output; instead print the name of each input file from which output would have been printed.")
+	fl.BoolVar(&app.filesWithout, "L", false, "Suppress normal output; instead print the name of each input file from which no output would have been printed.")
+	fl.BoolVar(&app.binaryText, "a", false, "Process a binary file as if it were text. Without it lines of files with NUL bytes are not printed, only -c, -l and -L report them.")
+	fl.BoolVar(&app.lineRegexp, "x", false, "Select only those matches that exactly match the whole line.")
+	fl.BoolVar(&app.wordRegexp, "w", false, "Select only those lines containing matches that form whole words: "+
+		"a match is preceded and followed by the line edge or a character that is not a letter, digit or underscore.")
+	fl.Var(&app.patterns, "e", "Use `PATTERN` for matching, lines matching any of patterns are selected. Can be repeated.")
+	fl.Var(&app.patternFiles, "f", "Obtain patterns from `FILE`, one per line. An empty file contains zero patterns and matches nothing. Can be repeated.")
 
-	if err := fl.Parse(args); err != nil {
+	if err := fl.Parse(splitShortFlags(fl, args)); err != nil {
 		fl.Usage()
 		return err
 	}
@@ -59,123 +116,414 @@ func (app *appEnv) fromArgs(args []string) error {
 	if app.before == 0 {
 		app.before = app.context
 	}
-
-	app.pattern = fl.Arg(0)
-	if app.isFixed {
-		app.pattern = fmt.Sprintf("^%s$", app.pattern)
+	if app.after < 0 || app.before < 0 {
+		err := fmt.Errorf("context length should not be negative")
+		fmt.Fprintf(os.Stderr, "invalid context: %v\n", err)
+		return err
+	}
+	if app.filesWith && app.filesWithout {
+		err := fmt.Errorf("-l and -L can't be used together")
+		fmt.Fprintf(os.Stderr, "invalid options: %v\n", err)
+		return err
 	}
-	if app.ignoreCase {
-		app.pattern = "(?i)" + app.pattern
+	for _, glob := range append(append(append([]string(nil), app.includes...), app.excludes...), app.excludeDirs...) {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid glob %s: %v\n", glob, err)
+			return err
+		}
+	}
+	app.recursive = app.recursive || app.dereference
+	// only matching parts and counts have no context lines
+	if app.onlyMatching || app.count {
+		app.before, app.after = 0, 0
+	}
+	app.colors = &colors{}
+	if app.color.enabled() {
+		app.colors = parseColors(os.Getenv("GREP_COLORS"), os.Getenv("GREP_COLOR"))
 	}
 
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		app.reader = os.Stdin
-		return nil
+	// positional pattern is used only without -e and -f, pattern with newlines is several patterns
+	args = fl.Args()
+	if len(app.patterns) == 0 && len(app.patternFiles) == 0 && len(args) > 0 {
+		app.patterns = append(app.patterns, args[0])
+		args = args[1:]
+	}
+	var patterns []string
+	for _, p := range app.patterns {
+		patterns = append(patterns, strings.Split(p, "\n")...)
+	}
+	for _, name := range app.patternFiles {
+		filePatterns, err := readPatterns(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't read patterns from %s: %v\n", name, err)
+			return err
+		}
+		patterns = append(patterns, filePatterns...)
 	}
 
-	file, err := os.Open(fl.Arg(1))
+	var err error
+	app.matcher, err = app.newMatcher(patterns)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "can't open file %s: %v\n", fl.Arg(0), err)
+		fmt.Fprintf(os.Stderr, "invalid pattern: %v\n", err)
 		return err
 	}
-	app.reader = file
+	app.writer = bufio.NewWriter(os.Stdout)
+
+	app.inputs = args
+	if len(app.inputs) == 0 {
+		app.inputs = []string{stdinName}
+		if app.recursive {
+			app.inputs = []string{"."}
+		}
+	}
+	if !app.withFilename && !app.noFilename {
+		app.withFilename = len(app.inputs) > 1 || app.recursive && isDir(app.inputs[0])
+	}
+	app.withFilename = app.withFilename && !app.noFilename
 
 	return nil
 }
 
+// run searches a single input, its output is flushed after every line when it is
+// standard input or output is a terminal, so it works on unbounded streams.
+// Several inputs are searched concurrently.
 func (app *appEnv) run() error {
-	defer app.reader.Close()
-	scanner := bufio.NewScanner(app.reader)
-	for scanner.Scan() {
-		app.input = append(app.input, scanner.Text())
+	if len(app.inputs) > 1 || app.recursive && isDir(app.inputs[0]) {
+		return app.searchAll()
 	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	name := app.inputs[0]
+	r, err := app.open(name)
+	if err != nil {
+		app.printError(name, err)
+		return errSkipped
 	}
+	defer r.Close()
+	_, err = app.search(r, name, app.writer, name == stdinName || isTerminal(os.Stdout))
+	return err
+}
 
-	r, err := regexp.Compile(app.pattern)
-	if err != nil {
-		return err
+// search matches input line by line: only the last app.before lines are kept for
+// leading context, trailing context is a countdown of lines left to print.
+// Output is flushed after every printed line when lineBuffered is set, otherwise only
+// once at the end of input. It reports whether any line is selected.
+func (app *appEnv) search(input io.Reader, name string, w *bufio.Writer, lineBuffered bool) (bool, error) {
+	r := bufio.NewReader(input)
+	// lines of binary input are not printed, but it is searched for -c, -l and -L
+	if !app.binaryText && isBinary(r) && !app.count && !app.filesWith && !app.filesWithout {
+		return false, nil
 	}
 
-	app.printResult(app.findMatched(r))
+	left := app.maxCount
+	count := 0
+	before := newRing(app.before)
+	afterLeft := 0
+	lastPrinted := 0
+	var offset int64
+	for num := 1; left != 0 || afterLeft > 0; num++ {
+		text, size, err := readLine(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return count > 0, err
+		}
+		line := numberedLine{num: num, offset: offset, text: text}
+		offset += int64(size)
 
-	return nil
-}
+		if left != 0 && app.matcher.match(text) != app.invert {
+			left--
+			count++
+			if app.filesWith || app.filesWithout {
+				break
+			}
+			if app.count {
+				continue
+			}
 
-func (app *appEnv) findMatched(r *regexp.Regexp) []int {
-	matched := make([]int, 0, len(app.input)/3)
+			first, ok := before.first()
+			if !ok {
+				first = line
+			}
+			if err = app.printSeparator(w, lastPrinted, first.num); err != nil {
+				return count > 0, err
+			}
+			err = before.drain(func(l numberedLine) error {
+				return app.printLine(w, name, l, '-')
+			})
+			if err != nil {
+				return count > 0, err
+			}
+			if err = app.printLine(w, name, line, ':'); err != nil {
+				return count > 0, err
+			}
+			if lineBuffered {
+				if err = w.Flush(); err != nil {
+					return count > 0, err
+				}
+			}
+			lastPrinted = num
+			afterLeft = app.after
+			continue
+		}
 
-	for i, v := range app.input {
-		if app.count == 0 {
-			break
+		if afterLeft > 0 {
+			if err = app.printLine(w, name, line, '-'); err != nil {
+				return count > 0, err
+			}
+			if lineBuffered {
+				if err = w.Flush(); err != nil {
+					return count > 0, err
+				}
+			}
+			lastPrinted = num
+			afterLeft--
+			continue
 		}
-		if r.MatchString(v) && !app.invert || !r.MatchString(v) && app.invert {
-			matched = append(matched, i)
-			app.count--
+		before.push(line)
+	}
+
+	selected := count > 0
+	switch {
+	case app.filesWith && selected || app.filesWithout && !selected:
+		app.colors.writeColored(w, app.colors.fileName, displayName(name))
+		w.WriteByte('\n')
+		return selected, w.Flush()
+	case app.filesWith || app.filesWithout:
+		return selected, nil
+	case app.count:
+		if app.withFilename {
+			app.colors.writeColored(w, app.colors.fileName, displayName(name))
+			app.colors.writeColored(w, app.colors.separator, ":")
 		}
+		fmt.Fprintf(w, "%d\n", count)
+		return selected, w.Flush()
 	}
+	return selected, w.Flush()
+}
 
-	return matched
+// isBinary reports whether the first buffered bytes of input have NUL,
+// it waits only for the first read, so streams are not delayed
+func isBinary(r *bufio.Reader) bool {
+	if _, err := r.Peek(1); err != nil {
+		return false
+	}
+	data, _ := r.Peek(min(r.Buffered(), binaryPeek))
+	return bytes.IndexByte(data, 0) >= 0
 }
 
-func (app *appEnv) printResult(matched []int) {
-	printed := make(map[int]struct{})
-	matchedM := make(map[int]struct{})
-	for _, v := range matched {
-		matchedM[v] = struct{}{}
+// readLine reads line without its newline and returns the number of bytes read,
+// the last line may have no newline
+func readLine(r *bufio.Reader) (string, int, error) {
+	line, err := r.ReadString('\n')
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
 	}
+	return strings.TrimSuffix(line, "\n"), len(line), err
+}
 
-	var lastPrinted int
-	for _, v := range matched {
-		if app.before > 0 || app.after > 0 {
-			if v-lastPrinted > 2 {
-				fmt.Println("--")
-			}
-			if _, ok := printed[v]; ok {
-				continue
-			}
+// printSeparator prints -- between groups of context lines that are not adjacent
+func (app *appEnv) printSeparator(w *bufio.Writer, lastPrinted, next int) error {
+	if app.before == 0 && app.after == 0 || lastPrinted == 0 || next == lastPrinted+1 {
+		return nil
+	}
+	app.colors.writeColored(w, app.colors.separator, "--")
+	return w.WriteByte('\n')
+}
 
-			start := v - app.before
-			if v-app.before < 0 {
-				start = 0
-			}
-			finish := v + app.after
-			if v+app.after > len(app.input)-1 {
-				finish = len(app.input) - 1
+// printLine prints selected line with sep ':' or context line with sep '-'. With -o
+// only matching parts of selected line are printed, each on its own line.
+func (app *appEnv) printLine(w *bufio.Writer, name string, l numberedLine, sep byte) error {
+	selected := sep == ':'
+	// GNU grep colors matches of selected lines, or of context lines with -v
+	matchColor, lineColor := app.colors.selectedMatch, app.colors.selectedLine
+	if !selected {
+		matchColor, lineColor = app.colors.contextMatch, app.colors.contextLine
+	}
+	if app.invert && app.colors.reverse {
+		lineColor = app.colors.selectedLine
+		if selected {
+			lineColor = app.colors.contextLine
+		}
+	}
+	if selected == app.invert {
+		matchColor = ""
+	}
+
+	var matches [][2]int
+	if app.onlyMatching || app.column && selected || matchColor != "" {
+		matches = allMatches(app.matcher, l.text)
+	}
+
+	if app.onlyMatching {
+		if app.invert {
+			return nil
+		}
+		for _, m := range matches {
+			app.printPrefix(w, name, l.num, m[0]+1, l.offset+int64(m[0]), sep)
+			app.colors.writeColored(w, matchColor, l.text[m[0]:m[1]])
+			if err := w.WriteByte('\n'); err != nil {
+				return err
 			}
+		}
+		return nil
+	}
 
-			for ; start <= finish; start++ {
-				if _, ok := printed[start]; ok {
-					continue
-				}
-				if _, ok := matchedM[start]; ok && start != v {
-					break
-				}
-				if app.printLineNum {
-					if _, ok := matchedM[start]; ok {
-						fmt.Printf("%d:%s\n", start+1, app.input[start])
-					} else {
-						fmt.Printf("%d-%s\n", start+1, app.input[start])
-					}
-					printed[start] = struct{}{}
-					lastPrinted = start
-					continue
-				}
-				fmt.Println(app.input[start])
-				printed[start] = struct{}{}
-				lastPrinted = start
+	column := 0
+	if selected {
+		column = 1
+		if len(matches) > 0 {
+			column = matches[0][0] + 1
+		}
+	}
+	app.printPrefix(w, name, l.num, column, l.offset, sep)
+	if matchColor == "" {
+		matches = nil
+	}
+	app.printText(w, l.text, matches, matchColor, lineColor)
+	return w.WriteByte('\n')
+}
+
+// printPrefix prints file name, line number, column and byte offset that are enabled,
+// each followed by sep. Zero column is not printed.
+func (app *appEnv) printPrefix(w *bufio.Writer, name string, num, column int, offset int64, sep byte) {
+	c := app.colors
+	if app.withFilename {
+		c.writeColored(w, c.fileName, displayName(name))
+		c.writeColored(w, c.separator, string(sep))
+	}
+	if app.printLineNum {
+		c.writeColored(w, c.lineNum, strconv.Itoa(num))
+		c.writeColored(w, c.separator, string(sep))
+	}
+	if app.column && column > 0 {
+		c.writeColored(w, c.lineNum, strconv.Itoa(column))
+		c.writeColored(w, c.separator, string(sep))
+	}
+	if app.byteOffset {
+		c.writeColored(w, c.byteOffset, strconv.FormatInt(offset, 10))
+		c.writeColored(w, c.separator, string(sep))
+	}
+}
+
+// printText prints line with matches colored like GNU grep does: line color
+// is restarted after every match, as match color ends with reset
+func (app *appEnv) printText(w *bufio.Writer, text string, matches [][2]int, matchColor, lineColor string) {
+	c := app.colors
+	pos := 0
+	for _, m := range matches {
+		if lineColor != "" {
+			w.WriteString(c.start(lineColor))
+		}
+		w.WriteString(text[pos:m[0]])
+		c.writeColored(w, matchColor, text[m[0]:m[1]])
+		pos = m[1]
+	}
+	if pos == len(text) && len(matches) > 0 {
+		return
+	}
+	if lineColor != "" {
+		w.WriteString(c.start(lineColor))
+	}
+	w.WriteString(text[pos:])
+	if lineColor != "" {
+		w.WriteString(c.end())
+	}
+}
 
+func (app *appEnv) printError(name string, err error) {
+	app.writer.Flush()
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	fmt.Fprintf(os.Stderr, "go-grep: %s: %v\n", displayName(name), err)
+}
+
+// displayName is input name used in output
+func displayName(name string) string {
+	if name == stdinName {
+		return "(standard input)"
+	}
+	return name
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+func isDir(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// splitShortFlags splits grouped short flags like -rn and short flags written
+// together with their values like -A2, so flag package can parse them
+func splitShortFlags(fl *flag.FlagSet, args []string) []string {
+	res := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			return append(res, args[i:]...)
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(arg, "--") || strings.Contains(name, "=") || fl.Lookup(name) != nil {
+			res = append(res, arg)
+			if !strings.Contains(name, "=") && fl.Lookup(name) != nil && !isBoolFlag(fl, name) && i+1 < len(args) {
+				i++
+				res = append(res, args[i])
 			}
 			continue
 		}
 
-		if app.printLineNum {
-			fmt.Printf("%d:%s\n", v+1, app.input[v])
-			continue
+		split := make([]string, 0, len(name))
+		for j := 0; j < len(name); j++ {
+			short := name[j : j+1]
+			if fl.Lookup(short) == nil {
+				// unknown flag is left for flag package to report
+				split = []string{arg}
+				break
+			}
+			split = append(split, "-"+short)
+			if isBoolFlag(fl, short) {
+				continue
+			}
+			if j+1 < len(name) {
+				split = append(split, name[j+1:])
+			} else if i+1 < len(args) {
+				i++
+				split = append(split, args[i])
+			}
+			break
 		}
-		fmt.Println(v)
+		res = append(res, split...)
 	}
+	return res
+}
+
+func isBoolFlag(fl *flag.FlagSet, name string) bool {
+	b, ok := fl.Lookup(name).Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// stringList is a flag.Value collecting repeated flags
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
 }
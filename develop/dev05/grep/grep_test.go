@@ -0,0 +1,482 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// grepString runs go-grep with args over input and returns its output
+func grepString(t *testing.T, args []string, input string) string {
+	t.Helper()
+	var app appEnv
+	require.NoError(t, app.fromArgs(args))
+
+	var out bytes.Buffer
+	_, err := app.search(strings.NewReader(input), stdinName, bufio.NewWriter(&out), false)
+	require.NoError(t, err)
+	return out.String()
+}
+
+// expected outputs are the ones of GNU grep 3.8 in C.UTF-8 locale
+func TestMatchSemantics(t *testing.T) {
+	const words = "a b\n\nfoo_bar\nfoo bar\nfoobar\n(foo)\nfoo\n"
+	const folding = "STRASSE\nstraße\nKelvin Kelvin\nſtop\nΣΊΣΥΦΟΣ\nёжик\nёж\nЁЖ ик\n"
+
+	testCases := []struct {
+		desc  string
+		args  []string
+		input string
+		want  string
+	}{
+		{
+			desc:  "fixed string is literal",
+			args:  []string{"-F", "a.b"},
+			input: "a.b\naxb\n",
+			want:  "a.b\n",
+		},
+		{
+			desc:  "fixed string is substring",
+			args:  []string{"-F", "oo"},
+			input: words,
+			want:  "foo_bar\nfoo bar\nfoobar\n(foo)\nfoo\n",
+		},
+		{
+			desc:  "fixed string with regexp characters",
+			args:  []string{"-F", "-e", "^(foo", "-e", "o_b"},
+			input: words + "^(foo\n",
+			want:  "foo_bar\n^(foo\n",
+		},
+		{
+			desc:  "regexp literal",
+			args:  []string{`foo\_?b`},
+			input: words,
+			want:  "foo_bar\nfoobar\n",
+		},
+		{
+			desc:  "whole line",
+			args:  []string{"-x", "foo"},
+			input: words,
+			want:  "foo\n",
+		},
+		{
+			desc:  "whole line fixed strings",
+			args:  []string{"-xF", "-e", "foo", "-e", "a b"},
+			input: words,
+			want:  "a b\nfoo\n",
+		},
+		{
+			desc:  "whole line regexp",
+			args:  []string{"-x", "-e", "fo+", "-e", "a.b"},
+			input: words,
+			want:  "a b\nfoo\n",
+		},
+		{
+			desc:  "empty whole line",
+			args:  []string{"-x", ""},
+			input: words,
+			want:  "\n",
+		},
+		{
+			desc:  "whole word",
+			args:  []string{"-w", "foo"},
+			input: words,
+			want:  "foo bar\n(foo)\nfoo\n",
+		},
+		{
+			desc:  "whole word fixed string",
+			args:  []string{"-wF", "foo"},
+			input: words,
+			want:  "foo bar\n(foo)\nfoo\n",
+		},
+		{
+			desc:  "whole word is checked for every occurrence",
+			args:  []string{"-wF", "-e", "o", "-e", "bar"},
+			input: words + "foo o\n",
+			want:  "foo bar\nfoo o\n",
+		},
+		{
+			desc:  "whole word regexp may extend match",
+			args:  []string{"-w", "foo.*"},
+			input: words,
+			want:  "foo_bar\nfoo bar\nfoobar\n(foo)\nfoo\n",
+		},
+		{
+			desc:  "whole word starting with space",
+			args:  []string{"-wF", " bar"},
+			input: words,
+			want:  "",
+		},
+		{
+			desc:  "empty whole word",
+			args:  []string{"-w", ""},
+			input: words,
+			want:  "\n(foo)\n",
+		},
+		{
+			desc:  "whole line wins over whole word",
+			args:  []string{"-x", "-w", "foo"},
+			input: words,
+			want:  "foo\n",
+		},
+		{
+			desc:  "ignore case fixed strings with unicode folding",
+			args:  []string{"-iF", "-e", "kelvin", "-e", "stop", "-e", "strasse", "-e", "σίσυφος"},
+			input: folding,
+			want:  "STRASSE\nKelvin Kelvin\nſtop\nΣΊΣΥΦΟΣ\n",
+		},
+		{
+			desc:  "ignore case regexp",
+			args:  []string{"-i", "-e", "kelvin", "-e", "ſ.op"},
+			input: folding,
+			want:  "Kelvin Kelvin\nſtop\n",
+		},
+		{
+			desc:  "ignore case whole word",
+			args:  []string{"-iwF", "ёж"},
+			input: folding,
+			want:  "ёж\nЁЖ ик\n",
+		},
+		{
+			desc:  "ignore case whole line",
+			args:  []string{"-ixF", "ЁЖ"},
+			input: folding,
+			want:  "ёж\n",
+		},
+		{
+			desc:  "invert whole word",
+			args:  []string{"-vwF", "foo"},
+			input: words,
+			want:  "a b\n\nfoo_bar\nfoobar\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.want, grepString(t, tC.args, tC.input))
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	const input = "a\nfoo\nb\nc\nd\nfoo\nfoo\ne\n"
+
+	testCases := []struct {
+		desc string
+		args []string
+		want string
+	}{
+		{
+			desc: "after",
+			args: []string{"-A", "1", "-n", "foo"},
+			want: "2:foo\n3-b\n--\n6:foo\n7:foo\n8-e\n",
+		},
+		{
+			desc: "before",
+			args: []string{"-B", "2", "foo"},
+			want: "a\nfoo\n--\nc\nd\nfoo\nfoo\n",
+		},
+		{
+			desc: "before at input start",
+			args: []string{"-B", "5", "-n", "^b"},
+			want: "1-a\n2-foo\n3:b\n",
+		},
+		{
+			desc: "after at input end",
+			args: []string{"-A", "5", "-n", "e"},
+			want: "8:e\n",
+		},
+		{
+			desc: "adjacent groups are joined",
+			args: []string{"-C", "1", "-n", "foo"},
+			want: "1-a\n2:foo\n3-b\n--\n5-d\n6:foo\n7:foo\n8-e\n",
+		},
+		{
+			desc: "overlapping context is printed once",
+			args: []string{"-A", "2", "-B", "2", "-n", "foo"},
+			want: "1-a\n2:foo\n3-b\n4-c\n5-d\n6:foo\n7:foo\n8-e\n",
+		},
+		{
+			desc: "context wider than gap between matches",
+			args: []string{"-C", "5", "-n", "foo"},
+			want: "1-a\n2:foo\n3-b\n4-c\n5-d\n6:foo\n7:foo\n8-e\n",
+		},
+		{
+			desc: "selected line ends trailing context",
+			args: []string{"-A", "3", "-n", "-e", "^a", "-e", "^c"},
+			want: "1:a\n2-foo\n3-b\n4:c\n5-d\n6-foo\n7-foo\n",
+		},
+		{
+			desc: "group separator between every group",
+			args: []string{"-B", "1", "-n", "-e", "foo", "-e", "e"},
+			want: "1-a\n2:foo\n--\n5-d\n6:foo\n7:foo\n8:e\n",
+		},
+		{
+			desc: "group separator with file name",
+			args: []string{"-H", "-A", "1", "^[ae]"},
+			want: "(standard input):a\n(standard input)-foo\n--\n(standard input):e\n",
+		},
+		{
+			desc: "no group separator without context",
+			args: []string{"-n", "foo"},
+			want: "2:foo\n6:foo\n7:foo\n",
+		},
+		{
+			desc: "inverted with context",
+			args: []string{"-v", "-A", "1", "-n", "[a-e]"},
+			want: "2:foo\n3-b\n--\n6:foo\n7:foo\n8-e\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.want, grepString(t, tC.args, input))
+		})
+	}
+}
+
+func TestContextColoredSeparator(t *testing.T) {
+	t.Setenv("GREP_COLORS", "ms=:ln=:se=35")
+	t.Setenv("GREP_COLOR", "")
+	got := grepString(t, []string{"--color=always", "-A", "1", "-n", "foo"}, "foo\nx\ny\nfoo\n")
+	assert.Equal(t, "1\x1b[35m\x1b[K:\x1b[m\x1b[Kfoo\n2\x1b[35m\x1b[K-\x1b[m\x1b[Kx\n"+
+		"\x1b[35m\x1b[K--\x1b[m\x1b[K\n4\x1b[35m\x1b[K:\x1b[m\x1b[Kfoo\n", got)
+}
+
+// lines are printed as soon as they are read, before input ends
+func TestSearchStream(t *testing.T) {
+	var app appEnv
+	require.NoError(t, app.fromArgs([]string{"-B", "1", "-A", "1", "foo"}))
+
+	in, inW := io.Pipe()
+	outR, out := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := app.search(in, stdinName, bufio.NewWriter(out), true)
+		out.Close()
+		done <- err
+	}()
+
+	lines := bufio.NewReader(outR)
+	readLine := func() string {
+		t.Helper()
+		line, err := lines.ReadString('\n')
+		require.NoError(t, err)
+		return line
+	}
+
+	_, err := io.WriteString(inW, "a\nb\nfoo\n")
+	require.NoError(t, err)
+	assert.Equal(t, "b\n", readLine())
+	assert.Equal(t, "foo\n", readLine())
+
+	_, err = io.WriteString(inW, "c\nd\ne\nfoo\n")
+	require.NoError(t, err)
+	assert.Equal(t, "c\n", readLine())
+	assert.Equal(t, "--\n", readLine())
+	assert.Equal(t, "e\n", readLine())
+	assert.Equal(t, "foo\n", readLine())
+
+	require.NoError(t, inW.Close())
+	rest, err := io.ReadAll(lines)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	require.NoError(t, <-done)
+}
+
+// countingWriter counts writes to it
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestSearchFlushes(t *testing.T) {
+	var app appEnv
+	require.NoError(t, app.fromArgs([]string{"-A", "1", "foo"}))
+	const input = "foo\na\nb\nfoo\nc\n"
+
+	out := &countingWriter{}
+	_, err := app.search(strings.NewReader(input), "a.txt", bufio.NewWriter(out), false)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\na\n--\nfoo\nc\n", out.String())
+	assert.Equal(t, 1, out.writes, "buffered output is flushed once")
+
+	out = &countingWriter{}
+	_, err = app.search(strings.NewReader(input), stdinName, bufio.NewWriter(out), true)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\na\n--\nfoo\nc\n", out.String())
+	assert.Equal(t, 4, out.writes, "line buffered output is flushed after every line")
+}
+
+func TestRing(t *testing.T) {
+	r := newRing(2)
+	_, ok := r.first()
+	assert.False(t, ok)
+
+	for num := 1; num <= 3; num++ {
+		r.push(numberedLine{num: num})
+	}
+	first, ok := r.first()
+	require.True(t, ok)
+	assert.Equal(t, 2, first.num)
+
+	var nums []int
+	require.NoError(t, r.drain(func(l numberedLine) error {
+		nums = append(nums, l.num)
+		return nil
+	}))
+	assert.Equal(t, []int{2, 3}, nums)
+	_, ok = r.first()
+	assert.False(t, ok)
+
+	// zero length ring keeps nothing
+	r = newRing(0)
+	r.push(numberedLine{num: 1})
+	_, ok = r.first()
+	assert.False(t, ok)
+}
+
+func TestOutputModes(t *testing.T) {
+	t.Setenv("GREP_COLORS", "")
+	t.Setenv("GREP_COLOR", "")
+	const input = "x foo foo\nbar\nfoo\nbaz\n"
+
+	testCases := []struct {
+		desc string
+		args []string
+		want string
+	}{
+		{
+			desc: "only matching",
+			args: []string{"-o", "-n", "fo*"},
+			want: "1:foo\n1:foo\n3:foo\n",
+		},
+		{
+			desc: "only matching prefers longest match",
+			args: []string{"-o", "-e", "a", "-e", "ba", "-e", "bar"},
+			want: "bar\nba\n",
+		},
+		{
+			desc: "only matching ignores context and inverted lines",
+			args: []string{"-o", "-v", "-A", "1", "foo"},
+			want: "",
+		},
+		{
+			desc: "byte offsets",
+			args: []string{"-b", "-A", "1", "foo"},
+			want: "0:x foo foo\n10-bar\n14:foo\n18-baz\n",
+		},
+		{
+			desc: "byte offsets of matching parts",
+			args: []string{"-ob", "foo"},
+			want: "2:foo\n6:foo\n14:foo\n",
+		},
+		{
+			desc: "column",
+			args: []string{"--column", "-n", "-A", "1", "foo"},
+			want: "1:3:x foo foo\n2-bar\n3:1:foo\n4-baz\n",
+		},
+		{
+			desc: "column of matching parts",
+			args: []string{"-o", "--column", "foo"},
+			want: "3:foo\n7:foo\n1:foo\n",
+		},
+		{
+			desc: "count",
+			args: []string{"-c", "foo"},
+			want: "2\n",
+		},
+		{
+			desc: "count inverted",
+			args: []string{"-c", "-v", "foo"},
+			want: "2\n",
+		},
+		{
+			desc: "count up to max count",
+			args: []string{"-c", "-m", "1", "foo"},
+			want: "1\n",
+		},
+		{
+			desc: "max count prints trailing context",
+			args: []string{"-m", "1", "-A", "2", "-n", "foo"},
+			want: "1:x foo foo\n2-bar\n3-foo\n",
+		},
+		{
+			desc: "zero max count",
+			args: []string{"-m", "0", "foo"},
+			want: "",
+		},
+		{
+			desc: "color",
+			args: []string{"--color=always", "-n", "foo"},
+			want: "\x1b[32m\x1b[K1\x1b[m\x1b[K\x1b[36m\x1b[K:\x1b[m\x1b[Kx \x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K \x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K\n" +
+				"\x1b[32m\x1b[K3\x1b[m\x1b[K\x1b[36m\x1b[K:\x1b[m\x1b[K\x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K\n",
+		},
+		{
+			desc: "color of context lines with invert",
+			args: []string{"--color=always", "-v", "-B", "1", "bar"},
+			want: "x foo foo\n\x1b[01;31m\x1b[Kbar\x1b[m\x1b[K\nfoo\nbaz\n",
+		},
+		{
+			desc: "never color",
+			args: []string{"--color=never", "foo"},
+			want: "x foo foo\nfoo\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.want, grepString(t, tC.args, input))
+		})
+	}
+}
+
+func TestLineColors(t *testing.T) {
+	t.Setenv("GREP_COLORS", "sl=1:cx=2:ms=4:ne")
+	got := grepString(t, []string{"--color=always", "-A", "1", "foo"}, "x foo foo\nbar\nfoo\n")
+	assert.Equal(t, "\x1b[1mx \x1b[4mfoo\x1b[m\x1b[1m \x1b[4mfoo\x1b[m\n\x1b[2mbar\x1b[m\n\x1b[1m\x1b[4mfoo\x1b[m\n", got)
+}
+
+func TestParseColors(t *testing.T) {
+	c := parseColors("mt=4:fn=:bad=x:ln=1;2:ne", "7")
+	assert.Equal(t, &colors{
+		selectedMatch: "4",
+		contextMatch:  "4",
+		lineNum:       "1;2",
+		byteOffset:    "32",
+		separator:     "36",
+		noErase:       true,
+	}, c)
+
+	c = parseColors("ms=x", "7")
+	assert.Equal(t, "7", c.selectedMatch)
+}
+
+func TestFoldString(t *testing.T) {
+	got, offsets := foldString("abC")
+	assert.Equal(t, "ABC", got)
+	assert.Nil(t, offsets)
+
+	// Kelvin sign is 3 bytes long, K is 1 byte long
+	got, offsets = foldString("aKс\xff")
+	assert.Equal(t, "AKС\xff", got)
+	assert.Equal(t, []int{0, 1, 4, 4, 6, 7}, offsets)
+}
+
+func TestAhoCorasickFind(t *testing.T) {
+	ac := newAhoCorasick([]string{"he", "she", "his", "hers"}, false)
+
+	var got [][2]int
+	ac.find("ushers", func(start, end int) bool {
+		got = append(got, [2]int{start, end})
+		return false
+	})
+	assert.Equal(t, [][2]int{{1, 4}, {2, 4}, {2, 6}}, got)
+}
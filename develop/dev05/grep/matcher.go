@@ -0,0 +1,328 @@
+package grep
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// matcher reports whether line matches any of patterns, matches appends byte
+// offsets of matches in line to dst, they may overlap
+type matcher interface {
+	match(line string) bool
+	matches(line string, dst [][2]int) [][2]int
+}
+
+// regexpMatcher matches re, matches are found by leftmost-longest find, its first group
+// is a match. With -w find has no trailing word check, so matches sharing a separator are
+// found, it is checked for every match.
+type regexpMatcher struct {
+	re   *regexp.Regexp
+	find *regexp.Regexp
+	word bool
+}
+
+func (m regexpMatcher) match(line string) bool {
+	return m.re.MatchString(line)
+}
+
+func (m regexpMatcher) matches(line string, dst [][2]int) [][2]int {
+	for _, loc := range m.find.FindAllStringSubmatchIndex(line, -1) {
+		if m.word && !isWordEnd(line, loc[3]) {
+			continue
+		}
+		dst = append(dst, [2]int{loc[2], loc[3]})
+	}
+	return dst
+}
+
+// anyMatcher matches when any of its matchers does
+type anyMatcher []matcher
+
+func (m anyMatcher) match(line string) bool {
+	for _, sub := range m {
+		if sub.match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m anyMatcher) matches(line string, dst [][2]int) [][2]int {
+	for _, sub := range m {
+		dst = sub.matches(line, dst)
+	}
+	return dst
+}
+
+// allMatches returns non-empty matches of line that do not overlap, like GNU grep
+// it selects the leftmost match and the longest one of matches starting there
+func allMatches(m matcher, line string) [][2]int {
+	found := m.matches(line, nil)
+	sort.Slice(found, func(i, j int) bool {
+		if found[i][0] != found[j][0] {
+			return found[i][0] < found[j][0]
+		}
+		return found[i][1] > found[j][1]
+	})
+
+	res := found[:0]
+	end := 0
+	for _, loc := range found {
+		if loc[0] < end || loc[0] == loc[1] {
+			continue
+		}
+		res = append(res, loc)
+		end = loc[1]
+	}
+	return res
+}
+
+// lineSet matches lines equal to one of fixed strings
+type lineSet struct {
+	lines    map[string]struct{}
+	foldCase bool
+}
+
+func newLineSet(patterns []string, foldCase bool) lineSet {
+	set := lineSet{lines: make(map[string]struct{}, len(patterns)), foldCase: foldCase}
+	for _, p := range patterns {
+		if foldCase {
+			p, _ = foldString(p)
+		}
+		set.lines[p] = struct{}{}
+	}
+	return set
+}
+
+func (m lineSet) match(line string) bool {
+	if m.foldCase {
+		line, _ = foldString(line)
+	}
+	_, ok := m.lines[line]
+	return ok
+}
+
+func (m lineSet) matches(line string, dst [][2]int) [][2]int {
+	if m.match(line) {
+		dst = append(dst, [2]int{0, len(line)})
+	}
+	return dst
+}
+
+// wordMatcher matches fixed strings that are whole words
+type wordMatcher struct {
+	ac *ahoCorasick
+}
+
+func (m wordMatcher) match(line string) bool {
+	return m.ac.find(line, func(start, end int) bool {
+		return isWordStart(line, start) && isWordEnd(line, end)
+	})
+}
+
+func (m wordMatcher) matches(line string, dst [][2]int) [][2]int {
+	m.ac.find(line, func(start, end int) bool {
+		if isWordStart(line, start) && isWordEnd(line, end) {
+			dst = append(dst, [2]int{start, end})
+		}
+		return false
+	})
+	return dst
+}
+
+// wordChars are characters that are parts of words for -w
+const wordChars = `\pL\pN_`
+
+// newMatcher compiles patterns once: fixed strings, and regular expressions that are
+// plain literals, are searched by Aho-Corasick automaton, other regular expressions
+// are combined into a single alternation. With -x patterns match whole lines, with -w
+// matches are preceded and followed by line edges or characters that are not parts of words.
+func (app *appEnv) newMatcher(patterns []string) (matcher, error) {
+	var literals, exprs []string
+	for _, p := range patterns {
+		lit, ok := p, app.isFixed
+		if !ok {
+			var err error
+			lit, ok, err = literalOf(p)
+			if err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case ok && lit == "" && app.wordRegexp && !app.lineRegexp:
+			// empty match is a word when it is between non-word characters, regexp checks it
+			exprs = append(exprs, "")
+		case ok:
+			literals = append(literals, lit)
+		case app.isFixed:
+			exprs = append(exprs, regexp.QuoteMeta(p))
+		default:
+			exprs = append(exprs, p)
+		}
+	}
+
+	var matchers anyMatcher
+	switch {
+	case len(literals) == 0:
+	case app.lineRegexp:
+		matchers = append(matchers, newLineSet(literals, app.ignoreCase))
+	case app.wordRegexp:
+		matchers = append(matchers, wordMatcher{ac: newAhoCorasick(literals, app.ignoreCase)})
+	default:
+		matchers = append(matchers, newAhoCorasick(literals, app.ignoreCase))
+	}
+
+	if len(exprs) > 0 {
+		var b strings.Builder
+		if app.ignoreCase {
+			b.WriteString("(?i)")
+		}
+		for i, p := range exprs {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			b.WriteString("(?:" + p + ")")
+		}
+		expr := b.String()
+		find := "(" + expr + ")"
+		switch {
+		case app.lineRegexp:
+			expr = "^(?:" + expr + ")$"
+			find = "^" + find + "$"
+		case app.wordRegexp:
+			expr = "(?:^|[^" + wordChars + "])(?:" + expr + ")(?:[^" + wordChars + "]|$)"
+			find = "(?:^|[^" + wordChars + "])" + find
+		}
+		m := regexpMatcher{word: app.wordRegexp && !app.lineRegexp}
+		var err error
+		if m.re, err = regexp.Compile(expr); err != nil {
+			return nil, err
+		}
+		if m.find, err = regexp.Compile(find); err != nil {
+			return nil, err
+		}
+		m.find.Longest()
+		matchers = append(matchers, m)
+	}
+
+	if len(matchers) == 1 {
+		return matchers[0], nil
+	}
+	// no patterns match nothing
+	return matchers, nil
+}
+
+// literalOf returns string matched by regular expression when it matches only that string
+func literalOf(expr string) (string, bool, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false, err
+	}
+	re = re.Simplify()
+	switch {
+	case re.Op == syntax.OpEmptyMatch:
+		return "", true, nil
+	case re.Op == syntax.OpLiteral && re.Flags&syntax.FoldCase == 0:
+		return string(re.Rune), true, nil
+	}
+	return "", false, nil
+}
+
+// readPatterns reads patterns from file, one per line, - is standard input
+func readPatterns(name string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if name != stdinName {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var patterns []string
+	br := bufio.NewReader(r)
+	for {
+		p, _, err := readLine(br)
+		if errors.Is(err, io.EOF) {
+			return patterns, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+}
+
+// isWordStart reports whether match starting at offset i of line is not preceded by a word character
+func isWordStart(line string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(line[:i])
+	return i == 0 || !isWordRune(r)
+}
+
+// isWordEnd reports whether match ending at offset i of line is not followed by a word character
+func isWordEnd(line string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(line[i:])
+	return i == len(line) || !isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// foldRune maps rune to the smallest rune of its simple case folding orbit,
+// so runes that differ only in case are mapped to the same rune
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r
+	}
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// foldString folds case of every rune of s, invalid UTF-8 is kept as is. Offsets map
+// byte offsets of the result to offsets of s, they are nil when offsets are the same.
+func foldString(s string) (string, []int) {
+	var b strings.Builder
+	b.Grow(len(s))
+	var offsets []int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteByte(s[i])
+		} else {
+			r = foldRune(r)
+			if offsets == nil && utf8.RuneLen(r) != size {
+				offsets = make([]int, b.Len(), len(s)+1)
+				for j := range offsets {
+					offsets[j] = j
+				}
+			}
+			b.WriteRune(r)
+		}
+		for offsets != nil && len(offsets) < b.Len() {
+			offsets = append(offsets, i)
+		}
+		i += size
+	}
+	if offsets != nil {
+		offsets = append(offsets, len(s))
+	}
+	return b.String(), offsets
+}
@@ -0,0 +1,181 @@
+package grep
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePatterns writes pattern file to a temp dir and returns its path
+func writePatterns(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "patterns")
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+	return name
+}
+
+func TestReadPatterns(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		content string
+		want    []string
+	}{
+		{desc: "empty file has no patterns", content: "", want: nil},
+		{desc: "one per line", content: "foo\nb.r\n", want: []string{"foo", "b.r"}},
+		{desc: "last line without newline", content: "foo\nbar", want: []string{"foo", "bar"}},
+		{desc: "empty line is empty pattern", content: "\n", want: []string{""}},
+		{desc: "empty lines between patterns", content: "foo\n\nbar\n", want: []string{"foo", "", "bar"}},
+		{desc: "spaces are kept", content: " foo \n", want: []string{" foo "}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := readPatterns(writePatterns(t, tC.content))
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+
+	_, err := readPatterns(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestMultiplePatterns(t *testing.T) {
+	const input = "foo\nbar\nbaz\nqux\nfoo.bar\n"
+	fooBar := writePatterns(t, "foo\nbar\n")
+	empty := writePatterns(t, "")
+	regexps := writePatterns(t, "^ba.$\nq+u")
+	emptyLine := writePatterns(t, "qux\n\n")
+
+	testCases := []struct {
+		desc string
+		args []string
+		want string
+	}{
+		{
+			desc: "repeated -e",
+			args: []string{"-e", "foo", "-e", "qux"},
+			want: "foo\nqux\nfoo.bar\n",
+		},
+		{
+			desc: "repeated -e mixes literals and regexps",
+			args: []string{"-e", "qux", "-e", "^ba[rz]$", "-e", "o\\.b"},
+			want: "bar\nbaz\nqux\nfoo.bar\n",
+		},
+		{
+			desc: "repeated -e fixed strings",
+			args: []string{"-F", "-e", "o.b", "-e", "ux"},
+			want: "qux\nfoo.bar\n",
+		},
+		{
+			desc: "repeated -e whole lines",
+			args: []string{"-x", "-e", "foo", "-e", "ba."},
+			want: "foo\nbar\nbaz\n",
+		},
+		{
+			desc: "-e with newline is several patterns",
+			args: []string{"-e", "qux\nbaz"},
+			want: "baz\nqux\n",
+		},
+		{
+			desc: "pattern file",
+			args: []string{"-f", fooBar},
+			want: "foo\nbar\nfoo.bar\n",
+		},
+		{
+			desc: "pattern file of regexps",
+			args: []string{"-f", regexps},
+			want: "bar\nbaz\nqux\n",
+		},
+		{
+			desc: "several pattern files",
+			args: []string{"-f", fooBar, "-f", regexps, "-x"},
+			want: "foo\nbar\nbaz\n",
+		},
+		{
+			desc: "pattern file with -e",
+			args: []string{"-e", "qux", "-f", fooBar, "-x"},
+			want: "foo\nbar\nqux\n",
+		},
+		{
+			desc: "empty pattern file matches nothing",
+			args: []string{"-f", empty},
+			want: "",
+		},
+		{
+			desc: "empty pattern file inverted matches everything",
+			args: []string{"-v", "-f", empty},
+			want: input,
+		},
+		{
+			desc: "empty pattern file with -e",
+			args: []string{"-f", empty, "-e", "baz"},
+			want: "baz\n",
+		},
+		{
+			desc: "empty line of pattern file matches every line",
+			args: []string{"-f", emptyLine},
+			want: input,
+		},
+		{
+			desc: "empty line of pattern file with -x",
+			args: []string{"-x", "-f", emptyLine},
+			want: "qux\n",
+		},
+		{
+			desc: "pattern file with ignore case",
+			args: []string{"-i", "-x", "-f", fooBar},
+			want: "foo\nbar\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.want, grepString(t, tC.args, input))
+		})
+	}
+}
+
+func TestManyFixedPatterns(t *testing.T) {
+	var ids, lines []string
+	for i := 0; i < 5000; i++ {
+		ids = append(ids, fmt.Sprintf("id-%05d", i*2))
+		lines = append(lines, fmt.Sprintf("request id-%05d done", i))
+	}
+	patterns := writePatterns(t, strings.Join(ids, "\n"))
+
+	got := grepString(t, []string{"-F", "-c", "-f", patterns}, strings.Join(lines, "\n"))
+	assert.Equal(t, "2500\n", got)
+	got = grepString(t, []string{"-F", "-w", "-f", patterns}, "request id-00002 done\nrequest id-000020\nid-00004\n")
+	assert.Equal(t, "request id-00002 done\nid-00004\n", got)
+}
+
+func TestPatternArgs(t *testing.T) {
+	patterns := writePatterns(t, "foo\n")
+
+	testCases := []struct {
+		desc   string
+		args   []string
+		inputs []string
+	}{
+		{desc: "positional pattern", args: []string{"foo", "a.txt"}, inputs: []string{"a.txt"}},
+		{desc: "no positional pattern with -e", args: []string{"-e", "foo", "a.txt", "b.txt"}, inputs: []string{"a.txt", "b.txt"}},
+		{desc: "no positional pattern with -f", args: []string{"-f", patterns, "a.txt", "b.txt"}, inputs: []string{"a.txt", "b.txt"}},
+		{desc: "standard input", args: []string{"-f", patterns}, inputs: []string{stdinName}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var app appEnv
+			require.NoError(t, app.fromArgs(tC.args))
+			assert.Equal(t, tC.inputs, app.inputs)
+		})
+	}
+
+	var app appEnv
+	assert.ErrorIs(t, app.fromArgs([]string{"-f", filepath.Join(t.TempDir(), "missing")}), os.ErrNotExist)
+	app = appEnv{}
+	assert.Error(t, app.fromArgs([]string{"-e", "foo", "-f", writePatterns(t, "a(\n")}))
+}
@@ -0,0 +1,53 @@
+package grep
+
+// numberedLine is an input line with its 1-based number and byte offset
+type numberedLine struct {
+	num    int
+	offset int64
+	text   string
+}
+
+// ring keeps the last lines that are not printed yet, they are leading context of the next match
+type ring struct {
+	lines []numberedLine
+	start int
+	size  int
+}
+
+func newRing(n int) *ring {
+	return &ring{lines: make([]numberedLine, n)}
+}
+
+// push adds line, the oldest line is dropped when ring is full
+func (r *ring) push(l numberedLine) {
+	if len(r.lines) == 0 {
+		return
+	}
+	if r.size < len(r.lines) {
+		r.lines[(r.start+r.size)%len(r.lines)] = l
+		r.size++
+		return
+	}
+	r.lines[r.start] = l
+	r.start = (r.start + 1) % len(r.lines)
+}
+
+// first returns the oldest line, ok is false when ring is empty
+func (r *ring) first() (numberedLine, bool) {
+	if r.size == 0 {
+		return numberedLine{}, false
+	}
+	return r.lines[r.start], true
+}
+
+// drain calls fn for lines from the oldest one and empties ring
+func (r *ring) drain(fn func(numberedLine) error) error {
+	for ; r.size > 0; r.size-- {
+		if err := fn(r.lines[r.start]); err != nil {
+			return err
+		}
+		r.start = (r.start + 1) % len(r.lines)
+	}
+	r.start = 0
+	return nil
+}
@@ -0,0 +1,203 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// gitDir is skipped by recursive search
+const gitDir = ".git"
+
+// job is an input searched by a worker, results are printed in the order of jobs
+type job struct {
+	name   string
+	result chan result
+}
+
+// result is output of a searched input
+type result struct {
+	out []byte
+	err error
+}
+
+// searchAll searches inputs with runtime.NumCPU() workers. Output of each input is
+// buffered and printed in the order inputs are found, errors are printed to stderr.
+func (app *appEnv) searchAll() error {
+	workers := runtime.NumCPU()
+	jobs := make(chan job)
+	ordered := make(chan job, 4*workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range jobs {
+				j.result <- app.searchFile(j.name)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(ordered)
+		app.walkInputs(func(name string, err error) {
+			j := job{name: name, result: make(chan result, 1)}
+			ordered <- j
+			if err != nil {
+				j.result <- result{err: err}
+				return
+			}
+			jobs <- j
+		})
+	}()
+
+	var failed, printed bool
+	for j := range ordered {
+		res := <-j.result
+		if res.err != nil {
+			app.printError(j.name, res.err)
+			failed = true
+			continue
+		}
+		if len(res.out) == 0 {
+			continue
+		}
+		// context groups of different files are separated too
+		if printed && (app.before > 0 || app.after > 0) && !app.filesWith && !app.filesWithout {
+			app.colors.writeColored(app.writer, app.colors.separator, "--")
+			app.writer.WriteByte('\n')
+		}
+		printed = true
+		if _, err := app.writer.Write(res.out); err != nil {
+			return err
+		}
+		if err := app.writer.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errSkipped
+	}
+	return nil
+}
+
+// searchFile searches input with buffered output
+func (app *appEnv) searchFile(name string) result {
+	r, err := app.open(name)
+	if err != nil {
+		return result{err: err}
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if _, err = app.search(r, name, w, false); err != nil {
+		return result{err: err}
+	}
+	return result{out: buf.Bytes()}
+}
+
+// open opens input file, - is standard input
+func (app *appEnv) open(name string) (io.ReadCloser, error) {
+	if name == stdinName {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// walkInputs calls visit for every input file, directories are walked when search
+// is recursive. Inputs that can't be read are visited with error.
+func (app *appEnv) walkInputs(visit func(name string, err error)) {
+	for _, name := range app.inputs {
+		if name == stdinName {
+			visit(name, nil)
+			continue
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			visit(name, err)
+			continue
+		}
+		if info.IsDir() && app.recursive {
+			app.walkDir(name, nil, []fs.FileInfo{info}, visit)
+			continue
+		}
+		if app.included(filepath.Base(name)) {
+			visit(name, nil)
+		}
+	}
+}
+
+// walkDir visits files of dir and walks its subdirectories. Symbolic links are followed
+// only with -R, ancestors are used to detect their loops.
+func (app *appEnv) walkDir(dir string, parent *ignoreList, ancestors []fs.FileInfo, visit func(string, error)) {
+	ignore, err := loadIgnore(dir, parent)
+	if err != nil {
+		visit(filepath.Join(dir, ignoreFile), err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		visit(dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+		var info fs.FileInfo
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if !app.dereference {
+				continue
+			}
+			info, err = os.Stat(name)
+		} else {
+			info, err = entry.Info()
+		}
+		if err != nil {
+			visit(name, err)
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			if entry.Name() == gitDir || matchAny(app.excludeDirs, entry.Name()) ||
+				ignore.ignored(name, true) || isAncestor(info, ancestors) {
+				continue
+			}
+			app.walkDir(name, ignore, append(ancestors, info), visit)
+		case info.Mode().IsRegular():
+			if app.included(entry.Name()) && !ignore.ignored(name, false) {
+				visit(name, nil)
+			}
+		}
+	}
+}
+
+// included reports whether file with base name passes --include and --exclude globs
+func (app *appEnv) included(base string) bool {
+	if len(app.includes) > 0 && !matchAny(app.includes, base) {
+		return false
+	}
+	return !matchAny(app.excludes, base)
+}
+
+func matchAny(globs []string, name string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isAncestor(info fs.FileInfo, ancestors []fs.FileInfo) bool {
+	for _, a := range ancestors {
+		if os.SameFile(info, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTree creates files with contents in a temp dir and returns its path
+func newTestTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+// grepFiles runs go-grep with args over inputs in dir, or over dir itself without inputs.
+// File names in output are relative to dir.
+func grepFiles(t *testing.T, dir string, args []string, inputs ...string) (string, error) {
+	t.Helper()
+	if len(inputs) == 0 {
+		args = append(args, dir)
+	}
+	for _, name := range inputs {
+		args = append(args, filepath.Join(dir, filepath.FromSlash(name)))
+	}
+
+	var app appEnv
+	require.NoError(t, app.fromArgs(args))
+	var out bytes.Buffer
+	app.writer = bufio.NewWriter(&out)
+	err := app.run()
+	return strings.ReplaceAll(out.String(), dir+string(filepath.Separator), ""), err
+}
+
+func TestRecursiveSearch(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		".gitignore":      "# build output\n/build/\n*.tmp\n",
+		".git/HEAD":       "foo\n",
+		"a.go":            "package a\nfoo\n",
+		"a_test.go":       "foo\n",
+		"b.txt":           "bar\n",
+		"bin.dat":         "foo\x00\n",
+		"bin_nomatch.dat": "\x00bar\n",
+		"build/out.go":    "foo\n",
+		"t.tmp":           "foo\n",
+		"sub/.gitignore":  "*.log\n!keep.log\n",
+		"sub/c.go":        "foo\n",
+		"sub/keep.log":    "foo\n",
+		"sub/x.log":       "foo\n",
+		"vendor/v.go":     "foo\n",
+	})
+	require.NoError(t, os.Symlink("sub", filepath.Join(dir, "link")))
+	require.NoError(t, os.Symlink("..", filepath.Join(dir, "sub", "up")))
+
+	testCases := []struct {
+		desc   string
+		args   []string
+		inputs []string
+		want   string
+	}{
+		{
+			desc: "recursive",
+			args: []string{"-r", "foo"},
+			want: "a.go:foo\na_test.go:foo\nsub/c.go:foo\nsub/keep.log:foo\nvendor/v.go:foo\n",
+		},
+		{
+			desc: "dereference follows links without loops",
+			args: []string{"-R", "-l", "foo"},
+			want: "a.go\na_test.go\nbin.dat\nlink/c.go\nlink/keep.log\nsub/c.go\nsub/keep.log\nvendor/v.go\n",
+		},
+		{
+			desc: "include",
+			args: []string{"-r", "--include", "*.go", "foo"},
+			want: "a.go:foo\na_test.go:foo\nsub/c.go:foo\nvendor/v.go:foo\n",
+		},
+		{
+			desc: "include and exclude",
+			args: []string{"-r", "--include=*.go", "--exclude", "*_test.go", "foo"},
+			want: "a.go:foo\nsub/c.go:foo\nvendor/v.go:foo\n",
+		},
+		{
+			desc: "exclude dir",
+			args: []string{"-r", "--exclude-dir", "vendor", "--exclude-dir", "s?b", "foo"},
+			want: "a.go:foo\na_test.go:foo\n",
+		},
+		{
+			desc: "files with matches",
+			args: []string{"-rl", "foo"},
+			want: "a.go\na_test.go\nbin.dat\nsub/c.go\nsub/keep.log\nvendor/v.go\n",
+		},
+		{
+			desc: "files without matches",
+			args: []string{"-rL", "foo"},
+			want: ".gitignore\nb.txt\nbin_nomatch.dat\nsub/.gitignore\n",
+		},
+		{
+			desc: "count",
+			args: []string{"-rc", "foo"},
+			want: ".gitignore:0\na.go:1\na_test.go:1\nb.txt:0\nbin.dat:1\nbin_nomatch.dat:0\n" +
+				"sub/.gitignore:0\nsub/c.go:1\nsub/keep.log:1\nvendor/v.go:1\n",
+		},
+		{
+			desc: "no file names",
+			args: []string{"-rh", "--include=*.go", "foo"},
+			want: "foo\nfoo\nfoo\nfoo\n",
+		},
+		{
+			desc: "context groups of files are separated",
+			args: []string{"-r", "-B", "1", "--include=a*.go", "foo"},
+			want: "a.go-package a\na.go:foo\n--\na_test.go:foo\n",
+		},
+		{
+			desc:   "single file without name",
+			args:   []string{"foo"},
+			inputs: []string{"a.go"},
+			want:   "foo\n",
+		},
+		{
+			desc:   "single file with name",
+			args:   []string{"-H", "foo"},
+			inputs: []string{"a.go"},
+			want:   "a.go:foo\n",
+		},
+		{
+			desc:   "several files with names",
+			args:   []string{"foo"},
+			inputs: []string{"a.go", "b.txt", "sub/c.go"},
+			want:   "a.go:foo\nsub/c.go:foo\n",
+		},
+		{
+			desc:   "several files without names",
+			args:   []string{"-h", "foo"},
+			inputs: []string{"a.go", "sub/c.go"},
+			want:   "foo\nfoo\n",
+		},
+		{
+			desc:   "globs filter explicit files",
+			args:   []string{"--exclude=*.log", "foo"},
+			inputs: []string{"a.go", "sub/x.log"},
+			want:   "a.go:foo\n",
+		},
+		{
+			desc:   "ignored files are searched when named",
+			args:   []string{"foo"},
+			inputs: []string{"t.tmp", "sub/x.log"},
+			want:   "t.tmp:foo\nsub/x.log:foo\n",
+		},
+		{
+			desc:   "binary lines are not printed",
+			args:   []string{"foo"},
+			inputs: []string{"bin.dat", "a_test.go"},
+			want:   "a_test.go:foo\n",
+		},
+		{
+			desc:   "binary as text",
+			args:   []string{"-a", "foo"},
+			inputs: []string{"bin.dat"},
+			want:   "foo\x00\n",
+		},
+		{
+			desc:   "binary count",
+			args:   []string{"-c", "foo"},
+			inputs: []string{"bin.dat", "bin_nomatch.dat"},
+			want:   "bin.dat:1\nbin_nomatch.dat:0\n",
+		},
+		{
+			desc:   "binary without matches",
+			args:   []string{"-L", "foo"},
+			inputs: []string{"bin.dat", "bin_nomatch.dat"},
+			want:   "bin_nomatch.dat\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := grepFiles(t, dir, tC.args, tC.inputs...)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestSearchMissingFile(t *testing.T) {
+	dir := newTestTree(t, map[string]string{"a.go": "foo\n"})
+
+	got, err := grepFiles(t, dir, []string{"foo"}, "a.go", "missing.go")
+	assert.ErrorIs(t, err, errSkipped)
+	assert.Equal(t, "a.go:foo\n", got)
+
+	_, err = grepFiles(t, dir, []string{"foo"}, "missing.go")
+	assert.ErrorIs(t, err, errSkipped)
+}
+
+func TestIsBinary(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		input string
+		want  bool
+	}{
+		{desc: "empty"},
+		{desc: "text", input: "foo\nbar\n"},
+		{desc: "utf-8", input: "ёж\n"},
+		{desc: "nul", input: "foo\x00bar\n", want: true},
+		{desc: "nul in the first block", input: strings.Repeat("x", binaryPeek-1) + "\x00", want: true},
+		{desc: "nul after the first block", input: strings.Repeat("x", binaryPeek) + "\x00"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tC.input))
+			assert.Equal(t, tC.want, isBinary(r))
+
+			// peeked bytes are still read
+			rest, err := r.ReadString(0)
+			if !tC.want {
+				assert.Equal(t, tC.input, rest)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(tC.input, rest))
+		})
+	}
+}
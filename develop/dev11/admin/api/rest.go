@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"calendar/http/middleware"
+	"calendar/http/render"
+)
+
+// Database is a database that supports maintenance while the app is running
+type Database interface {
+	Backup(w io.Writer) (int64, error)
+	Compact() (before, after int64, err error)
+}
+
+type API struct {
+	db     Database
+	token  string
+	logger *zap.Logger
+}
+
+func NewAPI(db Database, token string, logger *zap.Logger) API {
+	return API{
+		db:     db,
+		token:  token,
+		logger: logger,
+	}
+}
+
+// Register adds admin routes to mux, all of them require bearer token
+func (a *API) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/backup", middleware.Logger(middleware.BearerAuth(a.token, a.Backup)))
+	mux.HandleFunc("/admin/compact", middleware.Logger(middleware.BearerAuth(a.token, a.Compact)))
+}
+
+// Backup streams consistent snapshot of the database
+func (a *API) Backup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	name := fmt.Sprintf("calendar-%s.bdb", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+
+	// headers are already sent, so error can only be logged
+	if _, err := a.db.Backup(w); err != nil {
+		a.logger.Error("can't write backup", zap.Error(err))
+	}
+}
+
+// Compact rewrites database file to reclaim free space
+func (a *API) Compact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be post")
+		return
+	}
+
+	before, after, err := a.db.Compact()
+	if err != nil {
+		render.ErrorJSON(w, r, http.StatusInternalServerError, err, "can't compact database")
+		return
+	}
+
+	render.JSON(w, r, http.StatusOK, render.JSONMap{"size_before": before, "size_after": after})
+}
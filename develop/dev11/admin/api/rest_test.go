@@ -0,0 +1,133 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"calendar/event/repository/bolt"
+)
+
+func newTestAPI(t *testing.T) (*bolt.DB, http.Handler) {
+	t.Helper()
+
+	db, err := bolt.NewBoltDB(filepath.Join(t.TempDir(), "calendar.bdb"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := tx.CreateBucket([]byte("b"))
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte("k"), []byte("v"))
+	}))
+
+	api := NewAPI(db, "secret", zap.NewNop())
+	mux := http.NewServeMux()
+	api.Register(mux)
+	return db, mux
+}
+
+func serve(handler http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBackup(t *testing.T) {
+	_, handler := newTestAPI(t)
+
+	rec := serve(handler, http.MethodGet, "/admin/backup", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename="calendar-\d{8}T\d{6}Z\.bdb"$`, rec.Header().Get("Content-Disposition"))
+
+	// backup can be restored
+	path := filepath.Join(t.TempDir(), "restored.bdb")
+	require.NoError(t, bolt.RestoreFile(bytes.NewReader(rec.Body.Bytes()), path, time.Second))
+	restored, err := bolt.NewReadOnlyBoltDB(path, time.Second)
+	require.NoError(t, err)
+	defer restored.Close()
+	require.NoError(t, restored.View(func(tx *bbolt.Tx) error {
+		assert.Equal(t, []byte("v"), tx.Bucket([]byte("b")).Get([]byte("k")))
+		return nil
+	}))
+}
+
+func TestCompact(t *testing.T) {
+	db, handler := newTestAPI(t)
+
+	rec := serve(handler, http.MethodPost, "/admin/compact", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var sizes map[string]int64
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sizes))
+	assert.Contains(t, sizes, "size_before")
+	assert.Contains(t, sizes, "size_after")
+
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		assert.Equal(t, []byte("v"), tx.Bucket([]byte("b")).Get([]byte("k")))
+		return nil
+	}))
+}
+
+func TestAdminErrors(t *testing.T) {
+	_, handler := newTestAPI(t)
+
+	testCases := []struct {
+		desc   string
+		method string
+		target string
+		token  string
+		status int
+	}{
+		{
+			desc:   "backup without token",
+			method: http.MethodGet,
+			target: "/admin/backup",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "compact with wrong token",
+			method: http.MethodPost,
+			target: "/admin/compact",
+			token:  "wrong",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "backup bad method",
+			method: http.MethodPost,
+			target: "/admin/backup",
+			token:  "secret",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "compact bad method",
+			method: http.MethodGet,
+			target: "/admin/compact",
+			token:  "secret",
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rec := serve(handler, tC.method, tC.target, tC.token)
+			assert.Equal(t, tC.status, rec.Code)
+			assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,296 @@
+// Command calendarctl inspects and repairs calendar bolt database.
+// The server must be stopped, bolt file can't be opened while it is locked.
+//
+// Usage:
+//
+//	calendarctl [-db my.bdb] <command> [flags]
+//
+// Commands:
+//
+//	users                                     list users with number of events
+//	dump -user ID                             print user events as JSON
+//	create -user ID -title T -date RFC3339    create event, -duration and -tags are optional
+//	delete -user ID -id ID                    move event to trash
+//	migrate-users -org NAME [-admin ID]       create organization and user records for users created before organizations
+//	reindex                                   rebuild tag index from events
+//	verify                                    check database integrity, exit code is 1 if problems are found
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"calendar/event"
+	"calendar/event/repository/bolt"
+)
+
+// openTimeout is how long to wait for a database locked by another process
+const openTimeout = time.Second
+
+func main() {
+	fl := flag.NewFlagSet("calendarctl", flag.ContinueOnError)
+	dbPath := fl.String("db", envOr("DB_PATH", "my.bdb"), "path to bolt database file (env: DB_PATH)")
+	fl.Usage = func() {
+		fmt.Fprintln(fl.Output(), "usage: calendarctl [-db path] users|dump|create|delete|migrate-users|reindex|verify [flags]")
+		fl.PrintDefaults()
+	}
+	if err := fl.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		os.Exit(2)
+	}
+	if fl.NArg() == 0 {
+		fl.Usage()
+		os.Exit(2)
+	}
+
+	name, args := fl.Arg(0), fl.Args()[1:]
+	if err := run(name, args, *dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func run(name string, args []string, dbPath string) error {
+	switch name {
+	case "users":
+		return usersCmd(args, dbPath)
+	case "dump":
+		return dumpCmd(args, dbPath)
+	case "create":
+		return createCmd(args, dbPath)
+	case "delete":
+		return deleteCmd(args, dbPath)
+	case "migrate-users":
+		return migrateUsersCmd(args, dbPath)
+	case "reindex":
+		return reindexCmd(args, dbPath)
+	case "verify":
+		return verifyCmd(args, dbPath)
+	}
+
+	return fmt.Errorf("unknown command %q, available commands: users, dump, create, delete, migrate-users, reindex, verify", name)
+}
+
+// openDB opens database read-only unless write access is needed,
+// database file is created only when it is opened for writing
+func openDB(path string, write bool) (*bolt.DB, error) {
+	var db *bolt.DB
+	var err error
+	if write {
+		db, err = bolt.NewBoltDBWithTimeout(path, openTimeout)
+	} else {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		db, err = bolt.NewReadOnlyBoltDB(path, openTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't open database %s, stop the server first: %w", path, err)
+	}
+	return db, nil
+}
+
+func usersCmd(args []string, dbPath string) error {
+	fl := flag.NewFlagSet("users", flag.ContinueOnError)
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	users, err := bolt.Users(db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tORG\tNAME\tACTIVE\tEVENTS\tTRASH")
+	for _, u := range users {
+		if u.OrgID == 0 {
+			fmt.Fprintf(w, "%d\t-\t-\t-\t%d\t%d\n", u.ID, u.Events, u.Trash)
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%t\t%d\t%d\n", u.ID, u.OrgID, u.Name, u.Active, u.Events, u.Trash)
+	}
+	return w.Flush()
+}
+
+func dumpCmd(args []string, dbPath string) error {
+	fl := flag.NewFlagSet("dump", flag.ContinueOnError)
+	user := fl.Uint64("user", 0, "user id")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	events, err := bolt.UserEvents(db, *user)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(events)
+}
+
+func createCmd(args []string, dbPath string) error {
+	fl := flag.NewFlagSet("create", flag.ContinueOnError)
+	user := fl.Uint64("user", 0, "user id")
+	title := fl.String("title", "", "event title")
+	date := fl.String("date", "", "event date in RFC3339 format")
+	duration := fl.Duration("duration", 0, "event duration")
+	tags := fl.String("tags", "", "comma-separated event tags")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	if *title == "" {
+		return fmt.Errorf("no title provided")
+	}
+	t, err := time.Parse(time.RFC3339, *date)
+	if err != nil {
+		return fmt.Errorf("can't parse date, use RFC3339 format: %w", err)
+	}
+	if *duration < 0 {
+		return fmt.Errorf("negative duration %s", *duration)
+	}
+
+	db, err := openDB(dbPath, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	store := bolt.NewBoltEventRepository(db)
+	e, err := store.Create(*user, event.Event{
+		Title:    *title,
+		Date:     t,
+		Duration: event.Duration(*duration),
+		Tags:     event.NormalizeTags([]string{*tags}),
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(e)
+}
+
+func deleteCmd(args []string, dbPath string) error {
+	fl := flag.NewFlagSet("delete", flag.ContinueOnError)
+	user := fl.Uint64("user", 0, "user id")
+	id := fl.Uint64("id", 0, "event id")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	store := bolt.NewBoltEventRepository(db)
+	if err := store.Delete(*user, *id); err != nil {
+		return err
+	}
+	fmt.Printf("event %d of user %d moved to trash\n", *id, *user)
+
+	return nil
+}
+
+func migrateUsersCmd(args []string, dbPath string) error {
+	fl := flag.NewFlagSet("migrate-users", flag.ContinueOnError)
+	org := fl.String("org", "", "name of organization for migrated users")
+	admin := fl.Uint64("admin", 0, "id of user that becomes organization admin, defaults to the smallest id")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	if *org == "" {
+		return fmt.Errorf("no organization name provided")
+	}
+
+	db, err := openDB(dbPath, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	n, err := bolt.MigrateUsers(db, *org, *admin)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("migrated %d users\n", n)
+
+	return nil
+}
+
+func reindexCmd(args []string, dbPath string) error {
+	fl := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	n, err := bolt.Reindex(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("reindexed %d events\n", n)
+
+	return nil
+}
+
+func verifyCmd(args []string, dbPath string) error {
+	fl := flag.NewFlagSet("verify", flag.ContinueOnError)
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	problems, err := bolt.Verify(db)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems", len(problems))
+	}
+	fmt.Println("no problems found")
+
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
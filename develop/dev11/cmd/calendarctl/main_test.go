@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	"calendar/event"
+	"calendar/event/repository/bolt"
+)
+
+// newTestDB creates database with organization and returns its path and admin
+func newTestDB(t *testing.T) (string, event.User) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "calendar.bdb")
+	db, err := bolt.NewBoltDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, admin, err := bolt.NewBoltUserRepository(db).CreateOrg(event.Organization{Name: "acme"}, event.User{Name: "alice"})
+	require.NoError(t, err)
+	return path, admin
+}
+
+func userEvents(t *testing.T, path string, user_id uint64) []event.Event {
+	t.Helper()
+
+	db, err := bolt.NewReadOnlyBoltDB(path, openTimeout)
+	require.NoError(t, err)
+	defer db.Close()
+
+	events, err := bolt.UserEvents(db, user_id)
+	require.NoError(t, err)
+	return events
+}
+
+func TestCommands(t *testing.T) {
+	path, _ := newTestDB(t)
+
+	require.NoError(t, run("create", []string{"-user", "1", "-title", "sync", "-date", "2022-07-05T10:00:00Z", "-duration", "1h", "-tags", "Meeting,oncall"}, path))
+	require.NoError(t, run("create", []string{"-user", "1", "-title", "review", "-date", "2022-07-06T10:00:00Z"}, path))
+	events := userEvents(t, path, 1)
+	require.Len(t, events, 2)
+	assert.Equal(t, "sync", events[0].Title)
+	assert.Equal(t, []string{"meeting", "oncall"}, events[0].Tags)
+
+	require.NoError(t, run("delete", []string{"-user", "1", "-id", "1"}, path))
+	events = userEvents(t, path, 1)
+	require.Len(t, events, 1)
+	assert.Equal(t, "review", events[0].Title)
+
+	for _, name := range []string{"users", "reindex", "verify"} {
+		assert.NoError(t, run(name, nil, path), name)
+	}
+	assert.NoError(t, run("dump", []string{"-user", "1"}, path))
+}
+
+func TestCommandErrors(t *testing.T) {
+	path, _ := newTestDB(t)
+
+	testCases := []struct {
+		desc    string
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			desc:    "unknown command",
+			name:    "drop",
+			wantErr: `unknown command "drop"`,
+		},
+		{
+			desc:    "create without title",
+			name:    "create",
+			args:    []string{"-user", "1", "-date", "2022-07-05T10:00:00Z"},
+			wantErr: "no title provided",
+		},
+		{
+			desc:    "create with bad date",
+			name:    "create",
+			args:    []string{"-user", "1", "-title", "sync", "-date", "tomorrow"},
+			wantErr: "can't parse date",
+		},
+		{
+			desc:    "create for missing user",
+			name:    "create",
+			args:    []string{"-user", "5", "-title", "sync", "-date", "2022-07-05T10:00:00Z"},
+			wantErr: "user 5",
+		},
+		{
+			desc:    "dump missing user",
+			name:    "dump",
+			args:    []string{"-user", "5"},
+			wantErr: "user 5 does not exist",
+		},
+		{
+			desc:    "migrate without organization",
+			name:    "migrate-users",
+			wantErr: "no organization name provided",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.ErrorContains(t, run(tC.name, tC.args, path), tC.wantErr)
+		})
+	}
+}
+
+func TestMissingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.bdb")
+
+	// read-only commands don't create database
+	assert.Error(t, run("users", nil, path))
+	assert.NoFileExists(t, path)
+}
+
+func TestLockedDatabase(t *testing.T) {
+	path, _ := newTestDB(t)
+
+	// running server holds the lock
+	db, err := bolt.NewBoltDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, name := range []string{"users", "verify", "reindex"} {
+		assert.ErrorContains(t, run(name, nil, path), "stop the server first", name)
+	}
+	err = run("create", []string{"-user", "1", "-title", "sync", "-date", "2022-07-05T10:00:00Z"}, path)
+	assert.ErrorContains(t, err, "stop the server first")
+}
+
+func TestMigrateUsers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.bdb")
+	db, err := bolt.NewBoltDB(path)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucket([]byte{0, 0, 0, 0, 0, 0, 0, 3})
+		return err
+	}))
+	require.NoError(t, db.Close())
+
+	assert.Error(t, run("verify", nil, path))
+	require.NoError(t, run("migrate-users", []string{"-org", "acme"}, path))
+	assert.NoError(t, run("verify", nil, path))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"calendar/event/repository/bolt"
+	"calendar/http/tlscert"
+)
+
+// openTimeout is how long commands wait for a database locked by running server
+const openTimeout = time.Second
+
+// runCommand runs maintenance subcommand on the database from config
+func runCommand(name string, args []string, config *Config) error {
+	switch name {
+	case "backup":
+		return backupCmd(args, config)
+	case "restore":
+		return restoreCmd(args, config)
+	case "compact":
+		return compactCmd(args, config)
+	case "gencert":
+		return gencertCmd(args, config)
+	}
+
+	return fmt.Errorf("unknown command %q, available commands: backup, restore, compact, gencert", name)
+}
+
+func backupCmd(args []string, config *Config) error {
+	fl := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := fl.String("o", "", "write backup to file instead of stdout")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := bolt.NewReadOnlyBoltDB(config.DBPath, openTimeout)
+	if err != nil {
+		return fmt.Errorf("can't open database %s, use /admin/backup endpoint if server is running: %w", config.DBPath, err)
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	_, err = db.Backup(w)
+	return err
+}
+
+func restoreCmd(args []string, config *Config) error {
+	fl := flag.NewFlagSet("restore", flag.ContinueOnError)
+	input := fl.String("i", "", "read backup from file instead of stdin")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if err := bolt.RestoreFile(r, config.DBPath, openTimeout); err != nil {
+		return fmt.Errorf("can't restore database %s, stop the server first: %w", config.DBPath, err)
+	}
+	return nil
+}
+
+func compactCmd(args []string, config *Config) error {
+	fl := flag.NewFlagSet("compact", flag.ContinueOnError)
+	output := fl.String("o", "", "write compacted database to file instead of replacing the current one")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		return bolt.CompactFile(config.DBPath, *output)
+	}
+
+	db, err := bolt.NewBoltDBWithTimeout(config.DBPath, openTimeout)
+	if err != nil {
+		return fmt.Errorf("can't open database %s, use /admin/compact endpoint if server is running: %w", config.DBPath, err)
+	}
+	defer db.Close()
+
+	before, after, err := db.Compact()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("compacted %s: %d -> %d bytes\n", config.DBPath, before, after)
+
+	return nil
+}
+
+func gencertCmd(args []string, config *Config) error {
+	fl := flag.NewFlagSet("gencert", flag.ContinueOnError)
+	certFile := fl.String("cert", "cert.pem", "certificate output file")
+	keyFile := fl.String("key", "key.pem", "private key output file")
+	hosts := fl.String("hosts", "localhost,127.0.0.1,::1", "comma-separated hostnames and IPs the certificate is valid for")
+	days := fl.Int("days", 365, "certificate validity in days")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+
+	err := tlscert.GenerateSelfSigned(*certFile, *keyFile, strings.Split(*hosts, ","), time.Duration(*days)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("self-signed certificate written to %s and %s, set TLS_CERT_FILE and TLS_KEY_FILE to use it\n", *certFile, *keyFile)
+
+	return nil
+}
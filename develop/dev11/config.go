@@ -1,27 +1,284 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/sethvargo/go-envconfig"
+	"gopkg.in/yaml.v3"
 )
 
+// defaultConfigFile is loaded when it exists and no other file is provided
+const defaultConfigFile = "app.env"
+
 // Config stores app configuration
 type Config struct {
-	DBPath            string `env:"DB_PATH,default=my.bdb"`
-	HTTPServerAddress string `env:"HTTP_SERVER_ADDRESS,default=0.0.0.0:8080"`
-	ReadTimeout       int    `env:"READ_TIMEOUT,default=5"`
-	IdleTimeout       int    `env:"IDLE_TIMEOUT,default=30"`
-	ShutdownTimeout   int    `env:"SHUTDOWN_TIMEOUT,default=10"`
+	DBPath            string `env:"DB_PATH,default=my.bdb" usage:"path to bolt database file"`
+	HTTPServerAddress string `env:"HTTP_SERVER_ADDRESS,default=0.0.0.0:8080" usage:"http server address"`
+	GRPCServerAddress string `env:"GRPC_SERVER_ADDRESS,default=0.0.0.0:9090" usage:"grpc server address, grpc is disabled when empty"`
+	ReadTimeout       int    `env:"READ_TIMEOUT,default=5" usage:"http read timeout in seconds"`
+	IdleTimeout       int    `env:"IDLE_TIMEOUT,default=30" usage:"http idle timeout in seconds"`
+	ShutdownTimeout   int    `env:"SHUTDOWN_TIMEOUT,default=10" usage:"graceful shutdown timeout in seconds"`
+	TLSCertFile       string `env:"TLS_CERT_FILE" usage:"path to TLS certificate, TLS is disabled when empty"`
+	TLSKeyFile        string `env:"TLS_KEY_FILE" usage:"path to TLS private key"`
+	TrashRetention    int    `env:"TRASH_RETENTION,default=720" usage:"hours deleted events are kept in trash"`
+	PurgeInterval     int    `env:"PURGE_INTERVAL,default=3600" usage:"interval in seconds between trash purges"`
+	AdminToken        string `env:"ADMIN_TOKEN" usage:"bearer token for admin endpoints, they are disabled when empty" secret:"true"`
+}
+
+// Options are command-line options that are not part of Config
+type Options struct {
+	ConfigFile  string
+	PrintConfig bool
+	// Args are arguments left after flags, the first one is subcommand name
+	Args []string
 }
 
-// NewConfig reads config from env and creates config struct
-func NewConfig() (*Config, error) {
+// NewConfig reads config from file, env and command-line flags and creates config struct.
+// Flags take precedence over env, env takes precedence over file, file takes precedence over defaults.
+func NewConfig(args []string) (*Config, Options, error) {
+	var opts Options
+	fl := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	fl.StringVar(&opts.ConfigFile, "config", "", "path to config file in env or YAML format (env: CONFIG_FILE, default: app.env if exists)")
+	fl.BoolVar(&opts.PrintConfig, "print-config", false, "print resulting config and exit")
+	flagValues := registerConfigFlags(fl)
+
+	if err := fl.Parse(args); err != nil {
+		return nil, opts, err
+	}
+	opts.Args = fl.Args()
+
+	// only flags set explicitly should override other sources
+	setFlags := make(map[string]string)
+	fl.Visit(func(f *flag.Flag) {
+		if key, ok := flagValues[f.Name]; ok {
+			setFlags[key] = f.Value.String()
+		}
+	})
+
+	fileValues, err := loadConfigFile(opts.ConfigFile)
+	if err != nil {
+		return nil, opts, err
+	}
+
 	ctx := context.Background()
 	var c Config
-	if err := envconfig.Process(ctx, &c); err != nil {
+	l := envconfig.MultiLookuper(
+		envconfig.MapLookuper(setFlags),
+		envconfig.OsLookuper(),
+		envconfig.MapLookuper(fileValues),
+	)
+	if err := envconfig.ProcessWith(ctx, &c, l); err != nil {
+		return nil, opts, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, opts, err
+	}
+
+	return &c, opts, nil
+}
+
+// ValidationError lists all invalid config values
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e, "; ")
+}
+
+// Validate checks that config values are usable
+func (c *Config) Validate() error {
+	var errs ValidationError
+
+	if c.DBPath == "" {
+		errs = append(errs, "DB_PATH must not be empty")
+	}
+
+	if err := validateAddress(c.HTTPServerAddress); err != nil {
+		errs = append(errs, "HTTP_SERVER_ADDRESS "+err.Error())
+	}
+	if c.GRPCServerAddress != "" {
+		if err := validateAddress(c.GRPCServerAddress); err != nil {
+			errs = append(errs, "GRPC_SERVER_ADDRESS "+err.Error())
+		}
+		if c.GRPCServerAddress == c.HTTPServerAddress {
+			errs = append(errs, "GRPC_SERVER_ADDRESS must differ from HTTP_SERVER_ADDRESS")
+		}
+	}
+
+	positive := []struct {
+		name  string
+		value int
+	}{
+		{"READ_TIMEOUT", c.ReadTimeout},
+		{"IDLE_TIMEOUT", c.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"TRASH_RETENTION", c.TrashRetention},
+		{"PURGE_INTERVAL", c.PurgeInterval},
+	}
+	for _, v := range positive {
+		if v.value <= 0 {
+			errs = append(errs, fmt.Sprintf("%s must be positive, got %d", v.name, v.value))
+		}
+	}
+
+	switch {
+	case c.TLSCertFile != "" && c.TLSKeyFile == "":
+		errs = append(errs, "TLS_KEY_FILE must be set when TLS_CERT_FILE is set")
+	case c.TLSCertFile == "" && c.TLSKeyFile != "":
+		errs = append(errs, "TLS_CERT_FILE must be set when TLS_KEY_FILE is set")
+	}
+	files := []struct {
+		name string
+		path string
+	}{
+		{"TLS_CERT_FILE", c.TLSCertFile},
+		{"TLS_KEY_FILE", c.TLSKeyFile},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			errs = append(errs, fmt.Sprintf("%s is not readable: %v", f.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateAddress(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%q must be host:port: %w", addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("%q has invalid port %q", addr, port)
+	}
+	return nil
+}
+
+// Print writes config in env file format, secrets are masked
+func (c *Config) Print(w io.Writer) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := envKey(t.Field(i))
+		value := fmt.Sprint(v.Field(i).Interface())
+		if t.Field(i).Tag.Get("secret") == "true" && value != "" {
+			value = "********"
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerConfigFlags adds flag for every config field, flag name is derived
+// from env name (HTTP_SERVER_ADDRESS -> http-server-address). It returns map from flag name to env name.
+func registerConfigFlags(fl *flag.FlagSet) map[string]string {
+	flags := make(map[string]string)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		key := envKey(t.Field(i))
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		fl.String(name, "", fmt.Sprintf("%s (env: %s)", t.Field(i).Tag.Get("usage"), key))
+		flags[name] = key
+	}
+	return flags
+}
+
+func envKey(f reflect.StructField) string {
+	return strings.SplitN(f.Tag.Get("env"), ",", 2)[0]
+}
+
+// loadConfigFile reads config file values, file format is chosen by extension
+func loadConfigFile(path string) (map[string]string, error) {
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		path = defaultConfigFile
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't open config file: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return parseYAML(f)
+	default:
+		return parseEnv(f)
+	}
+}
+
+// parseEnv parses KEY=VALUE lines, empty lines and lines starting with # are skipped
+func parseEnv(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("config file line %d: expected KEY=VALUE, got %q", n, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
 
-	return &c, nil
+	return values, nil
+}
+
+// parseYAML parses flat YAML mapping, keys may be written as env names or in lower case (db_path)
+func parseYAML(r io.Reader) (map[string]string, error) {
+	raw := make(map[string]interface{})
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("can't parse yaml config: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("yaml config key %q: nested values are not supported", k)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+
+	return values, nil
 }
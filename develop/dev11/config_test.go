@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestNewConfigPrecedence(t *testing.T) {
+	envFile := writeFile(t, "app.env", "# comment\nDB_PATH=file.bdb\nREAD_TIMEOUT=7\nexport IDLE_TIMEOUT=\"40\"\n")
+	yamlFile := writeFile(t, "app.yaml", "db_path: file.bdb\nread_timeout: 7\nIDLE_TIMEOUT: 40\n")
+
+	testCases := []struct {
+		desc string
+		file string
+	}{
+		{desc: "env file", file: envFile},
+		{desc: "yaml file", file: yamlFile},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("READ_TIMEOUT", "8")
+			t.Setenv("IDLE_TIMEOUT", "50")
+
+			c, opts, err := NewConfig([]string{"-config", tC.file, "-idle-timeout", "60", "backup", "-o", "b.bdb"})
+			require.NoError(t, err)
+
+			assert.Equal(t, "file.bdb", c.DBPath)
+			assert.Equal(t, 8, c.ReadTimeout)
+			assert.Equal(t, 60, c.IdleTimeout)
+			assert.Equal(t, 10, c.ShutdownTimeout)
+			assert.Equal(t, "0.0.0.0:8080", c.HTTPServerAddress)
+			assert.Equal(t, []string{"backup", "-o", "b.bdb"}, opts.Args)
+		})
+	}
+}
+
+func TestNewConfigMissingFile(t *testing.T) {
+	_, _, err := NewConfig([]string{"-config", filepath.Join(t.TempDir(), "missing.env")})
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	valid := Config{
+		DBPath:            "my.bdb",
+		HTTPServerAddress: "0.0.0.0:8080",
+		GRPCServerAddress: "0.0.0.0:9090",
+		ReadTimeout:       5,
+		IdleTimeout:       30,
+		ShutdownTimeout:   10,
+		TrashRetention:    720,
+		PurgeInterval:     3600,
+	}
+
+	testCases := []struct {
+		desc   string
+		modify func(c *Config)
+		want   ValidationError
+	}{
+		{
+			desc:   "valid",
+			modify: func(c *Config) {},
+		},
+		{
+			desc: "bad address and timeout",
+			modify: func(c *Config) {
+				c.HTTPServerAddress = "localhost:http-port"
+				c.ShutdownTimeout = 0
+			},
+			want: ValidationError{
+				"HTTP_SERVER_ADDRESS \"localhost:http-port\" has invalid port \"http-port\"",
+				"SHUTDOWN_TIMEOUT must be positive, got 0",
+			},
+		},
+		{
+			desc: "same http and grpc address",
+			modify: func(c *Config) {
+				c.GRPCServerAddress = c.HTTPServerAddress
+			},
+			want: ValidationError{
+				"GRPC_SERVER_ADDRESS must differ from HTTP_SERVER_ADDRESS",
+			},
+		},
+		{
+			desc: "tls key without cert",
+			modify: func(c *Config) {
+				c.TLSKeyFile = "/nonexistent/key.pem"
+			},
+			want: ValidationError{
+				"TLS_CERT_FILE must be set when TLS_KEY_FILE is set",
+				"TLS_KEY_FILE is not readable: stat /nonexistent/key.pem: no such file or directory",
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c := valid
+			tC.modify(&c)
+			err := c.Validate()
+			if tC.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tC.want, err)
+		})
+	}
+}
+
+func TestPrintMasksSecrets(t *testing.T) {
+	c := Config{DBPath: "my.bdb", AdminToken: "secret"}
+	buf := &bytes.Buffer{}
+	require.NoError(t, c.Print(buf))
+
+	assert.Contains(t, buf.String(), "DB_PATH=my.bdb\n")
+	assert.Contains(t, buf.String(), "ADMIN_TOKEN=********\n")
+	assert.NotContains(t, buf.String(), "secret")
+}
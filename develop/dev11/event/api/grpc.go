@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"calendar/event"
+	"calendar/event/api/pb"
+)
+
+// GRPCServer implements calendar gRPC service on top of event repository
+type GRPCServer struct {
+	pb.UnimplementedCalendarServer
+	eventStore event.EventRepository
+	logger     *zap.Logger
+}
+
+func NewGRPCServer(repository event.EventRepository, logger *zap.Logger) *GRPCServer {
+	return &GRPCServer{
+		eventStore: repository,
+		logger:     logger,
+	}
+}
+
+func (s *GRPCServer) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.Event, error) {
+	if req.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no title provided")
+	}
+	if err := req.GetDate().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad date: %v", err)
+	}
+	if req.GetDuration().AsDuration() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "negative duration")
+	}
+
+	e := event.Event{
+		Title:    req.GetTitle(),
+		Date:     req.GetDate().AsTime(),
+		Duration: event.Duration(req.GetDuration().AsDuration()),
+		Tags:     event.NormalizeTags(req.GetTags()),
+	}
+
+	result, err := s.eventStore.Create(req.GetUserId(), e)
+	if err != nil {
+		return nil, grpcError(err, "can't create event")
+	}
+
+	return toProto(result), nil
+}
+
+func (s *GRPCServer) UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*emptypb.Empty, error) {
+	if req.GetEvent().GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no title provided")
+	}
+	if err := req.GetEvent().GetDate().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad date: %v", err)
+	}
+	if req.GetEvent().GetDuration().AsDuration() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "negative duration")
+	}
+
+	e := event.Event{
+		ID:       req.GetEvent().GetId(),
+		Title:    req.GetEvent().GetTitle(),
+		Date:     req.GetEvent().GetDate().AsTime(),
+		Duration: event.Duration(req.GetEvent().GetDuration().AsDuration()),
+		Tags:     event.NormalizeTags(req.GetEvent().GetTags()),
+	}
+
+	if err := s.eventStore.Update(req.GetUserId(), e); err != nil {
+		return nil, grpcError(err, "can't update event")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*emptypb.Empty, error) {
+	if err := s.eventStore.Delete(req.GetUserId(), req.GetId()); err != nil {
+		return nil, grpcError(err, "can't delete event")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) GetForDay(ctx context.Context, req *pb.GetEventsRequest) (*pb.EventList, error) {
+	return s.getEvents(req.GetUserId(), req.GetDate(), pb.Period_PERIOD_DAY, req.GetFilter())
+}
+
+func (s *GRPCServer) GetForWeek(ctx context.Context, req *pb.GetEventsRequest) (*pb.EventList, error) {
+	return s.getEvents(req.GetUserId(), req.GetDate(), pb.Period_PERIOD_WEEK, req.GetFilter())
+}
+
+func (s *GRPCServer) GetForMonth(ctx context.Context, req *pb.GetEventsRequest) (*pb.EventList, error) {
+	return s.getEvents(req.GetUserId(), req.GetDate(), pb.Period_PERIOD_MONTH, req.GetFilter())
+}
+
+// ListEvents sends events of the period one message per event. Events are read
+// from the store into memory first and then streamed, so the database transaction
+// is not held open while a slow client receives them.
+func (s *GRPCServer) ListEvents(req *pb.ListEventsRequest, stream pb.Calendar_ListEventsServer) error {
+	list, err := s.getEvents(req.GetUserId(), req.GetDate(), req.GetPeriod(), req.GetFilter())
+	if err != nil {
+		return err
+	}
+
+	for _, e := range list.GetEvents() {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *GRPCServer) getEvents(user_id uint64, date *timestamppb.Timestamp, period pb.Period, f *pb.TagFilter) (*pb.EventList, error) {
+	if err := date.CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad date: %v", err)
+	}
+	t := date.AsTime()
+
+	var events []event.Event
+	var err error
+	switch period {
+	case pb.Period_PERIOD_DAY:
+		events, err = s.eventStore.GetForDay(user_id, t)
+	case pb.Period_PERIOD_WEEK:
+		events, err = s.eventStore.GetForWeek(user_id, t)
+	case pb.Period_PERIOD_MONTH:
+		events, err = s.eventStore.GetForMonth(user_id, t)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "bad period: %s", period)
+	}
+	if err != nil {
+		return nil, grpcError(err, "can't get events")
+	}
+
+	filter := event.TagFilter{
+		Tags:     event.NormalizeTags(f.GetTags()),
+		MatchAll: f.GetMatchAll(),
+	}
+	events = filter.Filter(events)
+
+	list := &pb.EventList{Events: make([]*pb.Event, 0, len(events))}
+	for _, e := range events {
+		list.Events = append(list.Events, toProto(e))
+	}
+
+	return list, nil
+}
+
+func toProto(e event.Event) *pb.Event {
+	result := &pb.Event{
+		Id:    e.ID,
+		Title: e.Title,
+		Date:  timestamppb.New(e.Date),
+		Tags:  e.Tags,
+	}
+	if e.Duration != 0 {
+		result.Duration = durationpb.New(time.Duration(e.Duration))
+	}
+	return result
+}
+
+// grpcError converts repository error to gRPC status
+func grpcError(err error, details string) error {
+	switch {
+	case errors.Is(err, event.ErrNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", details, err)
+	case errors.Is(err, event.ErrForbidden):
+		return status.Errorf(codes.PermissionDenied, "%s: %v", details, err)
+	case errors.Is(err, event.ErrConflict):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", details, err)
+	}
+
+	return status.Errorf(codes.Internal, "%s: %v", details, err)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"calendar/event"
+	"calendar/event/api/pb"
+	"calendar/event/repository/bolt"
+)
+
+// newGRPCClient starts calendar gRPC server over in-memory connection
+func newGRPCClient(t *testing.T, store event.EventRepository) pb.CalendarClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	pb.RegisterCalendarServer(srv, NewGRPCServer(store, zap.NewNop()))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewCalendarClient(conn)
+}
+
+func TestGRPCCreateEvent(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		store    *bolt.EventRepositoryMock
+		req      *pb.CreateEventRequest
+		wantCode codes.Code
+		calls    int
+	}{
+		{
+			desc: "success",
+			store: &bolt.EventRepositoryMock{
+				CreateFunc: func(user_id uint64, e event.Event) (event.Event, error) {
+					e.ID = 1
+					return e, nil
+				},
+			},
+			req:      &pb.CreateEventRequest{UserId: 3, Title: "birthday", Date: timestamppb.New(eventTime)},
+			wantCode: codes.OK,
+			calls:    1,
+		},
+		{
+			desc:     "empty title",
+			store:    &bolt.EventRepositoryMock{},
+			req:      &pb.CreateEventRequest{UserId: 3, Date: timestamppb.New(eventTime)},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "no date",
+			store:    &bolt.EventRepositoryMock{},
+			req:      &pb.CreateEventRequest{UserId: 3, Title: "birthday"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "store error",
+			store: &bolt.EventRepositoryMock{
+				CreateFunc: func(user_id uint64, e event.Event) (event.Event, error) {
+					return event.Event{}, event.ErrInternalServerError
+				},
+			},
+			req:      &pb.CreateEventRequest{UserId: 3, Title: "birthday", Date: timestamppb.New(eventTime)},
+			wantCode: codes.Internal,
+			calls:    1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			client := newGRPCClient(t, tC.store)
+
+			got, err := client.CreateEvent(context.Background(), tC.req)
+			assert.Equal(t, tC.wantCode, status.Code(err))
+			assert.Len(t, tC.store.CreateCalls(), tC.calls)
+			if tC.wantCode == codes.OK {
+				assert.Equal(t, tEvent.ID, got.GetId())
+				assert.Equal(t, tEvent.Title, got.GetTitle())
+				assert.True(t, tEvent.Date.Equal(got.GetDate().AsTime()))
+			}
+		})
+	}
+}
+
+func TestGRPCDeleteEventNotFound(t *testing.T) {
+	store := &bolt.EventRepositoryMock{
+		DeleteFunc: func(user_id uint64, event_id uint64) error {
+			return event.ErrNotFound
+		},
+	}
+	client := newGRPCClient(t, store)
+
+	_, err := client.DeleteEvent(context.Background(), &pb.DeleteEventRequest{UserId: 3, Id: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	require.Len(t, store.DeleteCalls(), 1)
+	assert.Equal(t, uint64(1), store.DeleteCalls()[0].Event_id)
+}
+
+func TestGRPCListEvents(t *testing.T) {
+	store := &bolt.EventRepositoryMock{
+		GetForWeekFunc: func(user_id uint64, week time.Time) ([]event.Event, error) {
+			return tEventForWeek, nil
+		},
+	}
+	client := newGRPCClient(t, store)
+
+	stream, err := client.ListEvents(context.Background(), &pb.ListEventsRequest{
+		UserId: 3,
+		Date:   timestamppb.New(eventTime),
+		Period: pb.Period_PERIOD_WEEK,
+	})
+	require.NoError(t, err)
+
+	got := make([]string, 0)
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, e.GetTitle())
+	}
+
+	assert.Equal(t, []string{"test", "123"}, got)
+	assert.Len(t, store.GetForWeekCalls(), 1)
+
+	stream, err = client.ListEvents(context.Background(), &pb.ListEventsRequest{UserId: 3, Date: timestamppb.New(eventTime)})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,431 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"calendar/event"
+	"calendar/event/repository/bolt"
+)
+
+// testServer runs real router with bolt repository stored in a temp file
+type testServer struct {
+	*httptest.Server
+	db     *bolt.DB
+	dbPath string
+}
+
+// newTestServer starts router from API.NewRouter, wrap can add handlers around it
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) *testServer {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("integration test")
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "calendar.bdb")
+	db, err := bolt.NewBoltDB(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	api := NewAPI(bolt.NewBoltEventRepository(db), bolt.NewBoltUserRepository(db), zap.NewNop())
+	var handler http.Handler = api.NewRouter()
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return &testServer{Server: srv, db: db, dbPath: dbPath}
+}
+
+// do sends form in request body for POST and PUT and in query otherwise,
+// http.Request.ParseForm reads body only for these methods
+func (s *testServer) do(t *testing.T, method, path string, form url.Values) *http.Response {
+	t.Helper()
+
+	var body io.Reader
+	target := s.URL + path
+	if method != http.MethodPost && method != http.MethodPut {
+		target += "?" + form.Encode()
+	} else {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, target, body)
+	require.NoError(t, err)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := s.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+// decode checks response status and decodes JSON body into v
+func (s *testServer) decode(t *testing.T, resp *http.Response, status int, v interface{}) {
+	t.Helper()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, status, resp.StatusCode, "response: %s", body)
+	if v != nil {
+		require.NoError(t, json.Unmarshal(body, v), "response: %s", body)
+	}
+}
+
+// createOrg creates organization, its admin gets the next user id.
+// Zero admin_id is not sent, only the first organization is created without it.
+func (s *testServer) createOrg(t *testing.T, admin_id uint64, name, adminName string) createOrgResponse {
+	t.Helper()
+
+	form := url.Values{"name": {name}, "admin_name": {adminName}}
+	if admin_id != 0 {
+		form.Set("admin_id", fmt.Sprint(admin_id))
+	}
+	var result createOrgResponse
+	s.decode(t, s.do(t, http.MethodPost, "/create_org", form), http.StatusCreated, &result)
+	return result
+}
+
+func (s *testServer) createUser(t *testing.T, org_id, admin_id uint64, name string) event.User {
+	t.Helper()
+
+	form := url.Values{"org_id": {fmt.Sprint(org_id)}, "admin_id": {fmt.Sprint(admin_id)}, "name": {name}}
+	var u event.User
+	s.decode(t, s.do(t, http.MethodPost, "/create_user", form), http.StatusCreated, &u)
+	return u
+}
+
+func (s *testServer) createEvent(t *testing.T, user_id, title, date string, extra url.Values) event.Event {
+	t.Helper()
+
+	form := url.Values{"user_id": {user_id}, "title": {title}, "date": {date}}
+	for k, v := range extra {
+		form[k] = v
+	}
+
+	var e event.Event
+	s.decode(t, s.do(t, http.MethodPost, "/create_event", form), http.StatusCreated, &e)
+	return e
+}
+
+func TestIntegrationEventLifecycle(t *testing.T) {
+	s := newTestServer(t, nil)
+	acme := s.createOrg(t, 0, "acme", "alice")
+	bob := s.createUser(t, acme.Org.ID, acme.Admin.ID, "bob")
+	require.Equal(t, uint64(1), acme.Admin.ID)
+	require.Equal(t, uint64(2), bob.ID)
+
+	sync := s.createEvent(t, "1", "sync", "2022-07-05T10:00:00Z", url.Values{"duration": {"1h"}, "tags": {"Meeting"}})
+	release := s.createEvent(t, "1", "release", "2022-07-07T15:00:00Z", url.Values{"duration": {"30m"}, "tags": {"release,meeting"}})
+	other := s.createEvent(t, "2", "review", "2022-07-05T12:00:00Z", nil)
+	assert.Equal(t, uint64(1), sync.ID)
+	assert.Equal(t, uint64(2), release.ID)
+	assert.Equal(t, uint64(1), other.ID)
+	assert.Equal(t, []string{"meeting", "release"}, release.Tags)
+
+	t.Run("query periods", func(t *testing.T) {
+		var events []event.Event
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {"1"}, "date": {"2022-07-05T00:00:00Z"}}), http.StatusOK, &events)
+		require.Len(t, events, 1)
+		assert.Equal(t, "sync", events[0].Title)
+
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_week", url.Values{"user_id": {"1"}, "date": {"2022-07-05T00:00:00Z"}}), http.StatusOK, &events)
+		assert.Len(t, events, 2)
+
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_month", url.Values{"user_id": {"1"}, "date": {"2022-07-20T00:00:00Z"}, "tags": {"release"}}), http.StatusOK, &events)
+		require.Len(t, events, 1)
+		assert.Equal(t, "release", events[0].Title)
+
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_range", url.Values{"user_id": {"1"}, "from": {"2022-07-01T00:00:00Z"}, "to": {"2022-08-01T00:00:00Z"}, "tags": {"meeting,release"}, "tags_match": {"all"}}), http.StatusOK, &events)
+		require.Len(t, events, 1)
+		assert.Equal(t, release.ID, events[0].ID)
+
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {"1"}, "date": {"2022-07-06T00:00:00Z"}}), http.StatusNoContent, nil)
+	})
+
+	t.Run("tags and stats", func(t *testing.T) {
+		var tags []event.TagCount
+		s.decode(t, s.do(t, http.MethodGet, "/tags", url.Values{"user_id": {"1"}}), http.StatusOK, &tags)
+		assert.Equal(t, []event.TagCount{{Tag: "meeting", Count: 2}, {Tag: "release", Count: 1}}, tags)
+
+		var stats []event.Stats
+		s.decode(t, s.do(t, http.MethodGet, "/stats", url.Values{"user_id": {"1,2"}, "from": {"2022-07-01T00:00:00Z"}, "to": {"2022-08-01T00:00:00Z"}, "group_by": {"user"}}), http.StatusOK, &stats)
+		assert.Equal(t, []event.Stats{
+			{UserID: 1, Count: 2, Duration: event.Duration(90 * time.Minute)},
+			{UserID: 2, Count: 1},
+		}, stats)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		form := url.Values{"user_id": {"1"}, "id": {"1"}, "title": {"daily sync"}, "date": {"2022-07-06T10:00:00Z"}, "tags": {"oncall"}}
+		s.decode(t, s.do(t, http.MethodPut, "/update_event", form), http.StatusNoContent, nil)
+
+		var events []event.Event
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {"1"}, "date": {"2022-07-06T00:00:00Z"}}), http.StatusOK, &events)
+		require.Len(t, events, 1)
+		assert.Equal(t, "daily sync", events[0].Title)
+		assert.Equal(t, []string{"oncall"}, events[0].Tags)
+
+		// tag index follows the update
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_range", url.Values{"user_id": {"1"}, "from": {"2022-07-01T00:00:00Z"}, "to": {"2022-08-01T00:00:00Z"}, "tags": {"oncall"}}), http.StatusOK, &events)
+		require.Len(t, events, 1)
+		assert.Equal(t, uint64(1), events[0].ID)
+
+		var versions []event.EventVersion
+		s.decode(t, s.do(t, http.MethodGet, "/event_history", url.Values{"user_id": {"1"}, "id": {"1"}}), http.StatusOK, &versions)
+		require.Len(t, versions, 1)
+		assert.Equal(t, event.ActionUpdate, versions[0].Action)
+		assert.Equal(t, "sync", versions[0].Event.Title)
+	})
+
+	t.Run("delete and restore", func(t *testing.T) {
+		s.decode(t, s.do(t, http.MethodDelete, "/delete_event", url.Values{"user_id": {"1"}, "id": {"2"}}), http.StatusNoContent, nil)
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {"1"}, "date": {"2022-07-07T00:00:00Z"}}), http.StatusNoContent, nil)
+
+		var trash []event.TrashedEvent
+		s.decode(t, s.do(t, http.MethodGet, "/trash", url.Values{"user_id": {"1"}}), http.StatusOK, &trash)
+		require.Len(t, trash, 1)
+		assert.Equal(t, "release", trash[0].Title)
+
+		var restored event.Event
+		s.decode(t, s.do(t, http.MethodPost, "/restore_event", url.Values{"user_id": {"1"}, "id": {"2"}}), http.StatusOK, &restored)
+		assert.Equal(t, release.ID, restored.ID)
+
+		var events []event.Event
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {"1"}, "date": {"2022-07-07T00:00:00Z"}}), http.StatusOK, &events)
+		assert.Len(t, events, 1)
+		s.decode(t, s.do(t, http.MethodGet, "/trash", url.Values{"user_id": {"1"}}), http.StatusNoContent, nil)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var jsonErr jsonError
+		s.decode(t, s.do(t, http.MethodPut, "/update_event", url.Values{"user_id": {"1"}, "id": {"42"}, "title": {"x"}, "date": {"2022-07-06T10:00:00Z"}}), http.StatusNotFound, &jsonErr)
+		assert.Equal(t, "can't update event", jsonErr.Details)
+
+		s.decode(t, s.do(t, http.MethodDelete, "/delete_event", url.Values{"user_id": {"3"}, "id": {"1"}}), http.StatusNotFound, &jsonErr)
+		assert.Equal(t, "can't delete event", jsonErr.Details)
+
+		// users are not created implicitly
+		s.decode(t, s.do(t, http.MethodPost, "/create_event", url.Values{"user_id": {"3"}, "title": {"x"}, "date": {"2022-07-06T10:00:00Z"}}), http.StatusNotFound, &jsonErr)
+		assert.Equal(t, "can't create event", jsonErr.Details)
+
+		s.decode(t, s.do(t, http.MethodGet, "/create_event", url.Values{"user_id": {"1"}}), http.StatusBadRequest, &jsonErr)
+		assert.Equal(t, "method should be post", jsonErr.Details)
+	})
+}
+
+func TestIntegrationPersistence(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.createOrg(t, 0, "acme", "alice")
+	created := s.createEvent(t, "1", "sync", "2022-07-05T10:00:00Z", url.Values{"tags": {"meeting"}})
+
+	s.Close()
+	require.NoError(t, s.db.Close())
+
+	db, err := bolt.NewBoltDB(s.dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := bolt.NewBoltEventRepository(db)
+	events, err := store.GetForRange(1, created.Date, created.Date.Add(time.Second), event.TagFilter{Tags: []string{"meeting"}})
+	require.NoError(t, err)
+	assert.Equal(t, []event.Event{created}, events)
+}
+
+func TestIntegrationGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	// create request is held until the server is shutting down
+	s := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/create_event" {
+				close(started)
+				<-release
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	s.createOrg(t, 0, "acme", "alice")
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		form := url.Values{"user_id": {"1"}, "title": {"sync"}, "date": {"2022-07-05T10:00:00Z"}}
+		resp, err := s.Client().PostForm(s.URL+"/create_event", form)
+		inFlight <- result{resp, err}
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- s.Config.Shutdown(ctx)
+	}()
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown finished before in-flight request: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	res := <-inFlight
+	require.NoError(t, res.err)
+	defer res.resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, res.resp.StatusCode)
+	require.NoError(t, <-shutdown)
+
+	_, err := s.Client().Get(s.URL + "/events_for_day?user_id=1&date=2022-07-05T00:00:00Z")
+	assert.Error(t, err, "server accepts requests after shutdown")
+
+	// the in-flight request was stored before shutdown completed
+	events, err := bolt.NewBoltEventRepository(s.db).GetForDay(1, time.Date(2022, 7, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "sync", events[0].Title)
+}
+
+func TestIntegrationUsers(t *testing.T) {
+	s := newTestServer(t, nil)
+	acme := s.createOrg(t, 0, "acme", "alice")
+	other := s.createOrg(t, acme.Admin.ID, "other", "carol")
+	bob := s.createUser(t, acme.Org.ID, acme.Admin.ID, "bob")
+	s.createEvent(t, fmt.Sprint(bob.ID), "sync", "2022-07-05T10:00:00Z", nil)
+
+	t.Run("listing", func(t *testing.T) {
+		var orgs []event.Organization
+		s.decode(t, s.do(t, http.MethodGet, "/orgs", url.Values{"admin_id": {fmt.Sprint(other.Admin.ID)}}), http.StatusOK, &orgs)
+		require.Len(t, orgs, 1)
+		assert.Equal(t, "other", orgs[0].Name)
+
+		var users []event.User
+		s.decode(t, s.do(t, http.MethodGet, "/users", url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}}), http.StatusOK, &users)
+		require.Len(t, users, 2)
+		assert.Equal(t, "alice", users[0].Name)
+		assert.True(t, users[0].Admin)
+		assert.Equal(t, "bob", users[1].Name)
+		assert.False(t, users[1].Admin)
+	})
+
+	t.Run("new users have no events", func(t *testing.T) {
+		var stats []event.Stats
+		form := url.Values{"user_id": {fmt.Sprintf("%d,%d", acme.Admin.ID, bob.ID)}, "from": {"2022-07-01T00:00:00Z"}, "to": {"2022-08-01T00:00:00Z"}, "group_by": {"user"}}
+		s.decode(t, s.do(t, http.MethodGet, "/stats", form), http.StatusOK, &stats)
+		assert.Equal(t, []event.Stats{{UserID: bob.ID, Count: 1}}, stats)
+
+		var jsonErr jsonError
+		form.Set("user_id", fmt.Sprintf("%d,%d", bob.ID, bob.ID+100))
+		s.decode(t, s.do(t, http.MethodGet, "/stats", form), http.StatusNotFound, &jsonErr)
+
+		user := url.Values{"user_id": {fmt.Sprint(acme.Admin.ID)}, "date": {"2022-07-05T00:00:00Z"}}
+		for _, path := range []string{"/events_for_day", "/events_for_week", "/events_for_month"} {
+			s.decode(t, s.do(t, http.MethodGet, path, user), http.StatusNoContent, nil)
+		}
+		form = url.Values{"user_id": {fmt.Sprint(acme.Admin.ID)}, "duration": {"1h"}, "from": {"2022-07-05T00:00:00Z"}, "to": {"2022-07-06T00:00:00Z"}}
+		var slots []event.Interval
+		s.decode(t, s.do(t, http.MethodGet, "/find_slots", form), http.StatusOK, &slots)
+		assert.NotEmpty(t, slots)
+	})
+
+	t.Run("only admins list and create organizations", func(t *testing.T) {
+		var jsonErr jsonError
+		s.decode(t, s.do(t, http.MethodPost, "/create_org", url.Values{"name": {"evil"}, "admin_name": {"eve"}}), http.StatusBadRequest, &jsonErr)
+		assert.Equal(t, "can't parse admin_id", jsonErr.Details)
+		s.decode(t, s.do(t, http.MethodPost, "/create_org", url.Values{"name": {"evil"}, "admin_name": {"eve"}, "admin_id": {fmt.Sprint(bob.ID)}}), http.StatusForbidden, &jsonErr)
+		assert.Equal(t, "not allowed", jsonErr.Details)
+
+		s.decode(t, s.do(t, http.MethodGet, "/orgs", nil), http.StatusBadRequest, &jsonErr)
+		s.decode(t, s.do(t, http.MethodGet, "/orgs", url.Values{"admin_id": {fmt.Sprint(bob.ID)}}), http.StatusForbidden, &jsonErr)
+		s.decode(t, s.do(t, http.MethodGet, "/orgs", url.Values{"admin_id": {"100"}}), http.StatusForbidden, &jsonErr)
+
+		s.decode(t, s.do(t, http.MethodGet, "/users", url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}}), http.StatusBadRequest, &jsonErr)
+		s.decode(t, s.do(t, http.MethodGet, "/users", url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(bob.ID)}}), http.StatusForbidden, &jsonErr)
+		s.decode(t, s.do(t, http.MethodGet, "/users", url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(other.Admin.ID)}}), http.StatusForbidden, &jsonErr)
+		assert.Equal(t, "not allowed", jsonErr.Details)
+	})
+
+	t.Run("only org admins manage users", func(t *testing.T) {
+		var jsonErr jsonError
+		form := url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(bob.ID)}, "name": {"eve"}}
+		s.decode(t, s.do(t, http.MethodPost, "/create_user", form), http.StatusForbidden, &jsonErr)
+		assert.Equal(t, "not allowed", jsonErr.Details)
+
+		form = url.Values{"user_id": {fmt.Sprint(bob.ID)}, "admin_id": {fmt.Sprint(other.Admin.ID)}, "name": {"robert"}}
+		s.decode(t, s.do(t, http.MethodPut, "/update_user", form), http.StatusForbidden, &jsonErr)
+
+		form = url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(other.Admin.ID)}, "name": {"mine"}}
+		s.decode(t, s.do(t, http.MethodPut, "/update_org", form), http.StatusForbidden, &jsonErr)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		form := url.Values{"user_id": {fmt.Sprint(bob.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}, "name": {"robert"}}
+		var u event.User
+		s.decode(t, s.do(t, http.MethodPut, "/update_user", form), http.StatusOK, &u)
+		assert.Equal(t, "robert", u.Name)
+		assert.True(t, u.Active)
+
+		form = url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}, "name": {"acme inc"}}
+		s.decode(t, s.do(t, http.MethodPut, "/update_org", form), http.StatusNoContent, nil)
+	})
+
+	t.Run("deactivate", func(t *testing.T) {
+		form := url.Values{"user_id": {fmt.Sprint(bob.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}, "active": {"false"}}
+		s.decode(t, s.do(t, http.MethodPut, "/update_user", form), http.StatusOK, nil)
+
+		var jsonErr jsonError
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {fmt.Sprint(bob.ID)}, "date": {"2022-07-05T00:00:00Z"}}), http.StatusForbidden, &jsonErr)
+		assert.Equal(t, "can't get events", jsonErr.Details)
+
+		form["active"] = []string{"true"}
+		s.decode(t, s.do(t, http.MethodPut, "/update_user", form), http.StatusOK, nil)
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {fmt.Sprint(bob.ID)}, "date": {"2022-07-05T00:00:00Z"}}), http.StatusOK, nil)
+	})
+
+	t.Run("last admin", func(t *testing.T) {
+		var jsonErr jsonError
+		form := url.Values{"user_id": {fmt.Sprint(acme.Admin.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}, "admin": {"false"}}
+		s.decode(t, s.do(t, http.MethodPut, "/update_user", form), http.StatusConflict, &jsonErr)
+		assert.Equal(t, "can't update user", jsonErr.Details)
+
+		s.decode(t, s.do(t, http.MethodDelete, "/delete_user", url.Values{"user_id": {fmt.Sprint(acme.Admin.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}}), http.StatusConflict, &jsonErr)
+	})
+
+	t.Run("delete cascades", func(t *testing.T) {
+		s.decode(t, s.do(t, http.MethodDelete, "/delete_user", url.Values{"user_id": {fmt.Sprint(bob.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}}), http.StatusNoContent, nil)
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {fmt.Sprint(bob.ID)}, "date": {"2022-07-05T00:00:00Z"}}), http.StatusNotFound, nil)
+
+		s.createEvent(t, fmt.Sprint(acme.Admin.ID), "standup", "2022-07-05T10:00:00Z", nil)
+		s.decode(t, s.do(t, http.MethodDelete, "/delete_org", url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(acme.Admin.ID)}}), http.StatusNoContent, nil)
+		s.decode(t, s.do(t, http.MethodGet, "/events_for_day", url.Values{"user_id": {fmt.Sprint(acme.Admin.ID)}, "date": {"2022-07-05T00:00:00Z"}}), http.StatusNotFound, nil)
+		s.decode(t, s.do(t, http.MethodGet, "/users", url.Values{"org_id": {fmt.Sprint(acme.Org.ID)}, "admin_id": {fmt.Sprint(other.Admin.ID)}}), http.StatusForbidden, nil)
+		s.decode(t, s.do(t, http.MethodGet, "/orgs", url.Values{"admin_id": {fmt.Sprint(acme.Admin.ID)}}), http.StatusForbidden, nil)
+
+		var users []event.User
+		s.decode(t, s.do(t, http.MethodGet, "/users", url.Values{"org_id": {fmt.Sprint(other.Org.ID)}, "admin_id": {fmt.Sprint(other.Admin.ID)}}), http.StatusOK, &users)
+		require.Len(t, users, 1)
+		assert.Equal(t, "carol", users[0].Name)
+	})
+}
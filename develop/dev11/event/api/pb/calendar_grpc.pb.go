@@ -0,0 +1,362 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: calendar.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Calendar_CreateEvent_FullMethodName = "/calendar.v1.Calendar/CreateEvent"
+	Calendar_UpdateEvent_FullMethodName = "/calendar.v1.Calendar/UpdateEvent"
+	Calendar_DeleteEvent_FullMethodName = "/calendar.v1.Calendar/DeleteEvent"
+	Calendar_GetForDay_FullMethodName   = "/calendar.v1.Calendar/GetForDay"
+	Calendar_GetForWeek_FullMethodName  = "/calendar.v1.Calendar/GetForWeek"
+	Calendar_GetForMonth_FullMethodName = "/calendar.v1.Calendar/GetForMonth"
+	Calendar_ListEvents_FullMethodName  = "/calendar.v1.Calendar/ListEvents"
+)
+
+// CalendarClient is the client API for Calendar service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CalendarClient interface {
+	CreateEvent(ctx context.Context, in *CreateEventRequest, opts ...grpc.CallOption) (*Event, error)
+	UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetForDay(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*EventList, error)
+	GetForWeek(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*EventList, error)
+	GetForMonth(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*EventList, error)
+	// ListEvents streams events of the period one by one
+	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (Calendar_ListEventsClient, error)
+}
+
+type calendarClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCalendarClient(cc grpc.ClientConnInterface) CalendarClient {
+	return &calendarClient{cc}
+}
+
+func (c *calendarClient) CreateEvent(ctx context.Context, in *CreateEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	out := new(Event)
+	err := c.cc.Invoke(ctx, Calendar_CreateEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Calendar_UpdateEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Calendar_DeleteEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) GetForDay(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*EventList, error) {
+	out := new(EventList)
+	err := c.cc.Invoke(ctx, Calendar_GetForDay_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) GetForWeek(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*EventList, error) {
+	out := new(EventList)
+	err := c.cc.Invoke(ctx, Calendar_GetForWeek_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) GetForMonth(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*EventList, error) {
+	out := new(EventList)
+	err := c.cc.Invoke(ctx, Calendar_GetForMonth_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (Calendar_ListEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Calendar_ServiceDesc.Streams[0], Calendar_ListEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calendarListEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Calendar_ListEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type calendarListEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *calendarListEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CalendarServer is the server API for Calendar service.
+// All implementations must embed UnimplementedCalendarServer
+// for forward compatibility
+type CalendarServer interface {
+	CreateEvent(context.Context, *CreateEventRequest) (*Event, error)
+	UpdateEvent(context.Context, *UpdateEventRequest) (*emptypb.Empty, error)
+	DeleteEvent(context.Context, *DeleteEventRequest) (*emptypb.Empty, error)
+	GetForDay(context.Context, *GetEventsRequest) (*EventList, error)
+	GetForWeek(context.Context, *GetEventsRequest) (*EventList, error)
+	GetForMonth(context.Context, *GetEventsRequest) (*EventList, error)
+	// ListEvents streams events of the period one by one
+	ListEvents(*ListEventsRequest, Calendar_ListEventsServer) error
+	mustEmbedUnimplementedCalendarServer()
+}
+
+// UnimplementedCalendarServer must be embedded to have forward compatible implementations.
+type UnimplementedCalendarServer struct {
+}
+
+func (UnimplementedCalendarServer) CreateEvent(context.Context, *CreateEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateEvent not implemented")
+}
+func (UnimplementedCalendarServer) UpdateEvent(context.Context, *UpdateEventRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEvent not implemented")
+}
+func (UnimplementedCalendarServer) DeleteEvent(context.Context, *DeleteEventRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEvent not implemented")
+}
+func (UnimplementedCalendarServer) GetForDay(context.Context, *GetEventsRequest) (*EventList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForDay not implemented")
+}
+func (UnimplementedCalendarServer) GetForWeek(context.Context, *GetEventsRequest) (*EventList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForWeek not implemented")
+}
+func (UnimplementedCalendarServer) GetForMonth(context.Context, *GetEventsRequest) (*EventList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForMonth not implemented")
+}
+func (UnimplementedCalendarServer) ListEvents(*ListEventsRequest, Calendar_ListEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
+}
+func (UnimplementedCalendarServer) mustEmbedUnimplementedCalendarServer() {}
+
+// UnsafeCalendarServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CalendarServer will
+// result in compilation errors.
+type UnsafeCalendarServer interface {
+	mustEmbedUnimplementedCalendarServer()
+}
+
+func RegisterCalendarServer(s grpc.ServiceRegistrar, srv CalendarServer) {
+	s.RegisterService(&Calendar_ServiceDesc, srv)
+}
+
+func _Calendar_CreateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).CreateEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_CreateEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).CreateEvent(ctx, req.(*CreateEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_UpdateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).UpdateEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_UpdateEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).UpdateEvent(ctx, req.(*UpdateEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_DeleteEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).DeleteEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_DeleteEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).DeleteEvent(ctx, req.(*DeleteEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_GetForDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).GetForDay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_GetForDay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).GetForDay(ctx, req.(*GetEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_GetForWeek_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).GetForWeek(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_GetForWeek_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).GetForWeek(ctx, req.(*GetEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_GetForMonth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).GetForMonth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calendar_GetForMonth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).GetForMonth(ctx, req.(*GetEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ListEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CalendarServer).ListEvents(m, &calendarListEventsServer{stream})
+}
+
+type Calendar_ListEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type calendarListEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *calendarListEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Calendar_ServiceDesc is the grpc.ServiceDesc for Calendar service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Calendar_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "calendar.v1.Calendar",
+	HandlerType: (*CalendarServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateEvent",
+			Handler:    _Calendar_CreateEvent_Handler,
+		},
+		{
+			MethodName: "UpdateEvent",
+			Handler:    _Calendar_UpdateEvent_Handler,
+		},
+		{
+			MethodName: "DeleteEvent",
+			Handler:    _Calendar_DeleteEvent_Handler,
+		},
+		{
+			MethodName: "GetForDay",
+			Handler:    _Calendar_GetForDay_Handler,
+		},
+		{
+			MethodName: "GetForWeek",
+			Handler:    _Calendar_GetForWeek_Handler,
+		},
+		{
+			MethodName: "GetForMonth",
+			Handler:    _Calendar_GetForMonth_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListEvents",
+			Handler:       _Calendar_ListEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "calendar.proto",
+}
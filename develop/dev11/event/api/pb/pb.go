@@ -0,0 +1,4 @@
+// Package pb contains generated gRPC code for the calendar service
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative calendar.proto
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,12 +16,14 @@ import (
 
 type API struct {
 	eventStore event.EventRepository
+	userStore  event.UserRepository
 	logger     *zap.Logger
 }
 
-func NewAPI(repository event.EventRepository, logger *zap.Logger) API {
+func NewAPI(repository event.EventRepository, users event.UserRepository, logger *zap.Logger) API {
 	return API{
 		eventStore: repository,
+		userStore:  users,
 		logger:     logger,
 	}
 }
@@ -34,104 +37,142 @@ func (a *API) NewRouter() *http.ServeMux {
 	mux.HandleFunc("/events_for_day", middleware.Logger(a.Get))
 	mux.HandleFunc("/events_for_week", middleware.Logger(a.Get))
 	mux.HandleFunc("/events_for_month", middleware.Logger(a.Get))
+	mux.HandleFunc("/events_for_range", middleware.Logger(a.GetForRange))
+	mux.HandleFunc("/tags", middleware.Logger(a.GetTags))
+	mux.HandleFunc("/stats", middleware.Logger(a.GetStats))
+	mux.HandleFunc("/settings", middleware.Logger(a.GetSettings))
+	mux.HandleFunc("/update_settings", middleware.Logger(a.UpdateSettings))
+	mux.HandleFunc("/find_slots", middleware.Logger(a.FindSlots))
+	mux.HandleFunc("/create_org", middleware.Logger(a.CreateOrg))
+	mux.HandleFunc("/update_org", middleware.Logger(a.UpdateOrg))
+	mux.HandleFunc("/delete_org", middleware.Logger(a.DeleteOrg))
+	mux.HandleFunc("/orgs", middleware.Logger(a.ListOrgs))
+	mux.HandleFunc("/create_user", middleware.Logger(a.CreateUser))
+	mux.HandleFunc("/update_user", middleware.Logger(a.UpdateUser))
+	mux.HandleFunc("/delete_user", middleware.Logger(a.DeleteUser))
+	mux.HandleFunc("/users", middleware.Logger(a.ListUsers))
+	mux.HandleFunc("/restore_event", middleware.Logger(a.Restore))
+	mux.HandleFunc("/trash", middleware.Logger(a.GetTrash))
+	mux.HandleFunc("/event_history", middleware.Logger(a.GetHistory))
 
 	return mux
 }
 
 func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be post")
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be post")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
 		return
 	}
 
 	err := r.ParseForm()
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse form")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
 		return
 	}
 
 	uid := r.FormValue("user_id")
 	user_id, err := strconv.Atoi(uid)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
 		return
 	}
 
 	date := r.FormValue("date")
 	t, err := time.Parse(time.RFC3339, date)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse date, use RFC3339 format")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse date, use RFC3339 format")
 		return
 	}
 
 	title := r.FormValue("title")
 	if title == "" {
-		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("empty title"), "no title provided")
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("empty title"), "no title provided")
+		return
+	}
+
+	duration, err := parseDuration(r.FormValue("duration"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse duration, use format like 1h30m")
 		return
 	}
 
 	e := event.Event{
-		Title: title,
-		Date:  t,
+		Title:    title,
+		Date:     t,
+		Duration: duration,
+		Tags:     event.NormalizeTags(r.Form["tags"]),
 	}
 
 	result, err := a.eventStore.Create(uint64(user_id), e)
 	if err != nil {
-		render.ErrorJSON(w, r, event.GetStatusCode(err), err, "can't create event")
+		render.Error(w, r, event.GetStatusCode(err), err, "can't create event")
 		return
 	}
 
-	render.JSON(w, r, http.StatusCreated, result)
+	render.Respond(w, r, http.StatusCreated, result)
 }
 
 func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be put")
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be put")
 		return
 	}
 
 	err := r.ParseForm()
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse form")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
 		return
 	}
 
 	uid := r.FormValue("user_id")
 	user_id, err := strconv.Atoi(uid)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
 		return
 	}
 
 	eid := r.FormValue("id")
 	event_id, err := strconv.Atoi(eid)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse id")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse id")
 		return
 	}
 
 	date := r.FormValue("date")
 	t, err := time.Parse(time.RFC3339, date)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse date, use RFC3339 format")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse date, use RFC3339 format")
 		return
 	}
 
 	title := r.FormValue("title")
 	if title == "" {
-		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("empty title"), "no title provided")
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("empty title"), "no title provided")
+		return
+	}
+
+	duration, err := parseDuration(r.FormValue("duration"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse duration, use format like 1h30m")
 		return
 	}
 
 	e := event.Event{
-		ID:    uint64(event_id),
-		Title: title,
-		Date:  t,
+		ID:       uint64(event_id),
+		Title:    title,
+		Date:     t,
+		Duration: duration,
+		Tags:     event.NormalizeTags(r.Form["tags"]),
 	}
 
 	err = a.eventStore.Update(uint64(user_id), e)
 	if err != nil {
-		render.ErrorJSON(w, r, event.GetStatusCode(err), err, "can't update event")
+		render.Error(w, r, event.GetStatusCode(err), err, "can't update event")
 		return
 	}
 
@@ -140,33 +181,33 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be delete")
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be delete")
 		return
 	}
 
 	err := r.ParseForm()
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse form")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
 		return
 	}
 
 	uid := r.FormValue("user_id")
 	user_id, err := strconv.Atoi(uid)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
 		return
 	}
 
 	eid := r.FormValue("id")
 	event_id, err := strconv.Atoi(eid)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse id")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse id")
 		return
 	}
 
 	err = a.eventStore.Delete(uint64(user_id), uint64(event_id))
 	if err != nil {
-		render.ErrorJSON(w, r, event.GetStatusCode(err), err, "can't delete event")
+		render.Error(w, r, event.GetStatusCode(err), err, "can't delete event")
 		return
 	}
 
@@ -175,21 +216,32 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) Get(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		render.ErrorJSON(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
 		return
 	}
 
 	uid := r.URL.Query().Get("user_id")
 	user_id, err := strconv.Atoi(uid)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
 		return
 	}
 
 	date := r.URL.Query().Get("date")
 	t, err := time.Parse(time.RFC3339, date)
 	if err != nil {
-		render.ErrorJSON(w, r, http.StatusBadRequest, err, "can't parse date, use RFC3339 format")
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse date, use RFC3339 format")
+		return
+	}
+
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse tags filter")
 		return
 	}
 
@@ -204,13 +256,512 @@ func (a *API) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		render.ErrorJSON(w, r, event.GetStatusCode(err), err, "can't get events")
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get events")
 		return
 	}
 
+	events = filter.Filter(events)
 	if len(events) == 0 {
 		render.NoContent(w, r)
 		return
 	}
-	render.JSON(w, r, http.StatusOK, events)
+	render.Respond(w, r, http.StatusOK, events)
+}
+
+// GetForRange returns events with date in [from, to)
+func (a *API) GetForRange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	uid := r.URL.Query().Get("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse from, use RFC3339 format")
+		return
+	}
+
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse to, use RFC3339 format")
+		return
+	}
+
+	if !from.Before(to) {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("from %s is not before to %s", from, to), "bad range")
+		return
+	}
+
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse tags filter")
+		return
+	}
+
+	events, err := a.eventStore.GetForRange(uint64(user_id), from, to, filter)
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get events")
+		return
+	}
+
+	if len(events) == 0 {
+		render.NoContent(w, r)
+		return
+	}
+	render.Respond(w, r, http.StatusOK, events)
+}
+
+// GetTags returns user tags with number of events
+func (a *API) GetTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	uid := r.URL.Query().Get("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	tags, err := a.eventStore.GetTags(uint64(user_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get tags")
+		return
+	}
+
+	if len(tags) == 0 {
+		render.NoContent(w, r)
+		return
+	}
+	render.Respond(w, r, http.StatusOK, tags)
+}
+
+// GetStats returns number of events and their total duration in [from, to)
+// for one or several users grouped by period, tag and user
+func (a *API) GetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	user_ids, err := parseUserIDs(r.URL.Query()["user_id"])
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse from, use RFC3339 format")
+		return
+	}
+
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse to, use RFC3339 format")
+		return
+	}
+
+	if !from.Before(to) {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("from %s is not before to %s", from, to), "bad range")
+		return
+	}
+
+	group, err := event.ParseStatsGroup(r.URL.Query()["group_by"])
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse group_by")
+		return
+	}
+
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse tags filter")
+		return
+	}
+
+	// periods are computed in time zone of from, users without events add nothing
+	stats := event.NewStatsAggregator(group, from.Location())
+	for _, user_id := range user_ids {
+		events, err := a.eventStore.GetForRange(user_id, from, to, filter)
+		if err != nil {
+			render.Error(w, r, event.GetStatusCode(err), err, "can't get events")
+			return
+		}
+		stats.Add(user_id, events)
+	}
+
+	result := stats.Result()
+	if len(result) == 0 {
+		render.NoContent(w, r)
+		return
+	}
+	render.Respond(w, r, http.StatusOK, result)
+}
+
+func (a *API) GetSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	uid := r.URL.Query().Get("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	settings, err := a.eventStore.GetSettings(uint64(user_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get settings")
+		return
+	}
+
+	render.Respond(w, r, http.StatusOK, settings)
+}
+
+// UpdateSettings changes user working hours, values that are not provided are kept
+func (a *API) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be put")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	uid := r.FormValue("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	settings, err := a.eventStore.GetSettings(uint64(user_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get settings")
+		return
+	}
+
+	if tz := r.FormValue("time_zone"); tz != "" {
+		settings.TimeZone = tz
+	}
+	if v := r.FormValue("work_start"); v != "" {
+		if settings.WorkStart, err = event.ParseClock(v); err != nil {
+			render.Error(w, r, http.StatusBadRequest, err, "can't parse work_start")
+			return
+		}
+	}
+	if v := r.FormValue("work_end"); v != "" {
+		if settings.WorkEnd, err = event.ParseClock(v); err != nil {
+			render.Error(w, r, http.StatusBadRequest, err, "can't parse work_end")
+			return
+		}
+	}
+	if _, ok := r.Form["work_days"]; ok {
+		if settings.WorkDays, err = event.ParseWeekdays(r.Form["work_days"]); err != nil {
+			render.Error(w, r, http.StatusBadRequest, err, "can't parse work_days")
+			return
+		}
+	}
+
+	if err := settings.Validate(); err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "invalid settings")
+		return
+	}
+
+	if err := a.eventStore.UpdateSettings(uint64(user_id), settings); err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't update settings")
+		return
+	}
+
+	render.Respond(w, r, http.StatusOK, settings)
+}
+
+// FindSlots returns intervals in [from, to) where all participants are free for duration,
+// within their working hours. Participants without events are considered free.
+func (a *API) FindSlots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	user_ids, err := parseUserIDs(r.URL.Query()["user_id"])
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	duration, err := parseDuration(r.URL.Query().Get("duration"))
+	if err == nil && duration == 0 {
+		err = fmt.Errorf("empty duration")
+	}
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse duration, use format like 1h30m")
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse from, use RFC3339 format")
+		return
+	}
+
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse to, use RFC3339 format")
+		return
+	}
+
+	if !from.Before(to) {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("from %s is not before to %s", from, to), "bad range")
+		return
+	}
+
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err == nil && limit < 0 {
+			err = fmt.Errorf("negative limit %d", limit)
+		}
+		if err != nil {
+			render.Error(w, r, http.StatusBadRequest, err, "can't parse limit")
+			return
+		}
+	}
+
+	free := make([][]event.Interval, 0, len(user_ids))
+	for _, user_id := range user_ids {
+		settings, err := a.eventStore.GetSettings(user_id)
+		if err != nil {
+			render.Error(w, r, event.GetStatusCode(err), err, "can't get settings")
+			return
+		}
+
+		events, err := a.eventStore.GetOverlapping(user_id, from, to)
+		if err != nil {
+			render.Error(w, r, event.GetStatusCode(err), err, "can't get events")
+			return
+		}
+
+		free = append(free, event.FreeIntervals(settings, events, from, to))
+	}
+
+	// slots are ranked by days in time zone of from
+	slots := event.FindSlots(free, time.Duration(duration), from.Location())
+	if limit > 0 && len(slots) > limit {
+		slots = slots[:limit]
+	}
+
+	if len(slots) == 0 {
+		render.NoContent(w, r)
+		return
+	}
+	render.Respond(w, r, http.StatusOK, slots)
+}
+
+func (a *API) Restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be post")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	uid := r.FormValue("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	eid := r.FormValue("id")
+	event_id, err := strconv.Atoi(eid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse id")
+		return
+	}
+
+	result, err := a.eventStore.Restore(uint64(user_id), uint64(event_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't restore event")
+		return
+	}
+
+	render.Respond(w, r, http.StatusOK, result)
+}
+
+func (a *API) GetTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	uid := r.URL.Query().Get("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	events, err := a.eventStore.GetTrash(uint64(user_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get deleted events")
+		return
+	}
+
+	if len(events) == 0 {
+		render.NoContent(w, r)
+		return
+	}
+	render.Respond(w, r, http.StatusOK, events)
+}
+
+func (a *API) GetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	uid := r.URL.Query().Get("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	eid := r.URL.Query().Get("id")
+	event_id, err := strconv.Atoi(eid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse id")
+		return
+	}
+
+	versions, err := a.eventStore.GetHistory(uint64(user_id), uint64(event_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get event history")
+		return
+	}
+
+	if len(versions) == 0 {
+		render.NoContent(w, r)
+		return
+	}
+	render.Respond(w, r, http.StatusOK, versions)
+}
+
+// parseUserIDs reads user ids from comma-separated or repeated values, duplicates are dropped
+func parseUserIDs(values []string) ([]uint64, error) {
+	seen := make(map[uint64]struct{})
+	ids := make([]uint64, 0, len(values))
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no user_id provided")
+	}
+	return ids, nil
+}
+
+// parseTagFilter reads tags filter from "tags" (comma-separated or repeated)
+// and "tags_match" (any or all) query params
+func parseTagFilter(r *http.Request) (event.TagFilter, error) {
+	matchAll, err := event.ParseTagMatch(r.URL.Query().Get("tags_match"))
+	if err != nil {
+		return event.TagFilter{}, err
+	}
+
+	return event.TagFilter{
+		Tags:     event.NormalizeTags(r.URL.Query()["tags"]),
+		MatchAll: matchAll,
+	}, nil
+}
+
+// parseDuration parses optional event duration, empty value means zero duration
+func parseDuration(value string) (event.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %s", d)
+	}
+
+	return event.Duration(d), nil
 }
@@ -625,3 +625,922 @@ func TestGet(t *testing.T) {
 		})
 	}
 }
+
+func TestRestore(t *testing.T) {
+	api := API{}
+	req := new(http.Request)
+
+	testCases := []struct {
+		desc           string
+		store          *bolt.EventRepositoryMock
+		reqBody        string
+		checkMockCalls func(tr *bolt.EventRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "success",
+			store: &bolt.EventRepositoryMock{
+				RestoreFunc: func(user_id uint64, event_id uint64) (event.Event, error) {
+					return tEvent, nil
+				},
+			},
+			reqBody: "user_id=3&id=1",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.RestoreCalls())
+				assert.Equal(t, 1, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := event.Event{}
+				err := json.NewDecoder(rec.Body).Decode(&got)
+				require.NoError(t, err)
+				assert.EqualValues(t, tEvent, got)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc:           "bad id",
+			store:          &bolt.EventRepositoryMock{},
+			reqBody:        "user_id=3&id=bad data",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				err := json.NewDecoder(rec.Body).Decode(&jsonErr)
+				require.NoError(t, err)
+				assert.EqualValues(t, "can't parse id", jsonErr.Details)
+				assert.EqualValues(t, "strconv.Atoi: parsing \"bad data\": invalid syntax", jsonErr.Error)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			desc: "event not in trash",
+			store: &bolt.EventRepositoryMock{
+				RestoreFunc: func(user_id uint64, event_id uint64) (event.Event, error) {
+					return event.Event{}, event.ErrNotFound
+				},
+			},
+			reqBody: "user_id=3&id=1",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.RestoreCalls())
+				assert.Equal(t, 1, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				err := json.NewDecoder(rec.Body).Decode(&jsonErr)
+				require.NoError(t, err)
+				assert.EqualValues(t, "can't restore event", jsonErr.Details)
+				assert.EqualValues(t, "your requested item is not found", jsonErr.Error)
+				assert.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			api.eventStore = tC.store
+
+			req = httptest.NewRequest("POST", "/restore_event", strings.NewReader(tC.reqBody))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			rec := httptest.NewRecorder()
+			api.Restore(rec, req)
+
+			tC.checkMockCalls(tC.store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
+
+func TestGetTrash(t *testing.T) {
+	api := API{}
+	req := new(http.Request)
+
+	trashed := []event.TrashedEvent{
+		{
+			Event:     tEvent,
+			DeletedAt: time.Date(2022, 7, 6, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	testCases := []struct {
+		desc           string
+		store          *bolt.EventRepositoryMock
+		user_id        string
+		checkMockCalls func(tr *bolt.EventRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "success",
+			store: &bolt.EventRepositoryMock{
+				GetTrashFunc: func(user_id uint64) ([]event.TrashedEvent, error) {
+					return trashed, nil
+				},
+			},
+			user_id: "3",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.GetTrashCalls())
+				assert.Equal(t, 1, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := []event.TrashedEvent{}
+				err := json.NewDecoder(rec.Body).Decode(&got)
+				require.NoError(t, err)
+				assert.EqualValues(t, trashed, got)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc: "empty trash",
+			store: &bolt.EventRepositoryMock{
+				GetTrashFunc: func(user_id uint64) ([]event.TrashedEvent, error) {
+					return []event.TrashedEvent{}, nil
+				},
+			},
+			user_id: "3",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.GetTrashCalls())
+				assert.Equal(t, 1, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			api.eventStore = tC.store
+
+			req = httptest.NewRequest("GET", "/trash", nil)
+			q := req.URL.Query()
+			q.Add("user_id", tC.user_id)
+			req.URL.RawQuery = q.Encode()
+
+			rec := httptest.NewRecorder()
+			api.GetTrash(rec, req)
+
+			tC.checkMockCalls(tC.store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	api := API{}
+	req := new(http.Request)
+
+	versions := []event.EventVersion{
+		{
+			Version:   1,
+			Action:    event.ActionUpdate,
+			ChangedAt: time.Date(2022, 7, 6, 10, 0, 0, 0, time.UTC),
+			Event:     tEvent,
+		},
+	}
+
+	testCases := []struct {
+		desc           string
+		store          *bolt.EventRepositoryMock
+		user_id        string
+		id             string
+		checkMockCalls func(tr *bolt.EventRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "success",
+			store: &bolt.EventRepositoryMock{
+				GetHistoryFunc: func(user_id uint64, event_id uint64) ([]event.EventVersion, error) {
+					return versions, nil
+				},
+			},
+			user_id: "3",
+			id:      "1",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.GetHistoryCalls())
+				assert.Equal(t, 1, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := []event.EventVersion{}
+				err := json.NewDecoder(rec.Body).Decode(&got)
+				require.NoError(t, err)
+				assert.EqualValues(t, versions, got)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc:           "bad id",
+			store:          &bolt.EventRepositoryMock{},
+			user_id:        "3",
+			id:             "bad data",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				err := json.NewDecoder(rec.Body).Decode(&jsonErr)
+				require.NoError(t, err)
+				assert.EqualValues(t, "can't parse id", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			desc: "user not found",
+			store: &bolt.EventRepositoryMock{
+				GetHistoryFunc: func(user_id uint64, event_id uint64) ([]event.EventVersion, error) {
+					return nil, event.ErrNotFound
+				},
+			},
+			user_id: "3",
+			id:      "1",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.GetHistoryCalls())
+				assert.Equal(t, 1, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				err := json.NewDecoder(rec.Body).Decode(&jsonErr)
+				require.NoError(t, err)
+				assert.EqualValues(t, "can't get event history", jsonErr.Details)
+				assert.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			api.eventStore = tC.store
+
+			req = httptest.NewRequest("GET", "/event_history", nil)
+			q := req.URL.Query()
+			q.Add("user_id", tC.user_id)
+			q.Add("id", tC.id)
+			req.URL.RawQuery = q.Encode()
+
+			rec := httptest.NewRecorder()
+			api.GetHistory(rec, req)
+
+			tC.checkMockCalls(tC.store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
+
+func TestGetNegotiation(t *testing.T) {
+	api := API{}
+
+	testCases := []struct {
+		desc           string
+		accept         string
+		checkMockCalls func(tr *bolt.EventRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc:   "csv",
+			accept: "text/csv",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.GetForMonthCalls())
+				assert.Equal(t, 1, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+				assert.Equal(t, "id,title,date,duration,tags\n1,test,2022-07-21T15:04:01Z,0s,\n1,123,2022-07-05T21:12:37Z,0s,\n", rec.Body.String())
+			},
+		},
+		{
+			desc:   "not acceptable",
+			accept: "text/html",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				calls := len(tr.GetForMonthCalls())
+				assert.Equal(t, 0, calls)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				err := json.NewDecoder(rec.Body).Decode(&jsonErr)
+				require.NoError(t, err)
+				assert.EqualValues(t, "unsupported Accept header", jsonErr.Details)
+				assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			store := &bolt.EventRepositoryMock{
+				GetForMonthFunc: func(user_id uint64, month time.Time) ([]event.Event, error) {
+					return tEventForMonth, nil
+				},
+			}
+			api.eventStore = store
+
+			req := httptest.NewRequest("GET", "/events_for_month?user_id=3&date=2022-07-05T15:04:01Z", nil)
+			req.Header.Set("Accept", tC.accept)
+
+			rec := httptest.NewRecorder()
+			api.Get(rec, req)
+
+			tC.checkMockCalls(store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
+
+// mutations are not done when response can't be sent in accepted format,
+// mocks without functions panic when they are called
+func TestMutationNegotiation(t *testing.T) {
+	api := API{eventStore: &bolt.EventRepositoryMock{}, userStore: &bolt.UserRepositoryMock{}}
+
+	testCases := []struct {
+		desc    string
+		method  string
+		target  string
+		body    string
+		handler http.HandlerFunc
+	}{
+		{
+			desc:    "create event",
+			method:  "POST",
+			target:  "/create_event",
+			body:    "user_id=3&date=2022-07-05T15:04:01Z&title=birthday",
+			handler: api.Create,
+		},
+		{
+			desc:    "restore event",
+			method:  "POST",
+			target:  "/restore_event",
+			body:    "user_id=3&id=1",
+			handler: api.Restore,
+		},
+		{
+			desc:    "update settings",
+			method:  "PUT",
+			target:  "/update_settings",
+			body:    "user_id=3&time_zone=UTC",
+			handler: api.UpdateSettings,
+		},
+		{
+			desc:    "create organization",
+			method:  "POST",
+			target:  "/create_org",
+			body:    "name=acme&admin_name=root",
+			handler: api.CreateOrg,
+		},
+		{
+			desc:    "create user",
+			method:  "POST",
+			target:  "/create_user",
+			body:    "org_id=1&admin_id=1&name=bob",
+			handler: api.CreateUser,
+		},
+		{
+			desc:    "update user",
+			method:  "PUT",
+			target:  "/update_user",
+			body:    "id=2&admin_id=1&name=bob",
+			handler: api.UpdateUser,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tC.method, tC.target, strings.NewReader(tC.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("Accept", "text/html")
+
+			rec := httptest.NewRecorder()
+			tC.handler(rec, req)
+
+			jsonErr := new(jsonError)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+			assert.EqualValues(t, "unsupported Accept header", jsonErr.Details)
+			assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+		})
+	}
+}
+
+func TestCreateWithTags(t *testing.T) {
+	api := API{}
+	store := &bolt.EventRepositoryMock{
+		CreateFunc: func(user_id uint64, e event.Event) (event.Event, error) {
+			e.ID = 1
+			return e, nil
+		},
+	}
+	api.eventStore = store
+
+	req := httptest.NewRequest("POST", "/create_event", strings.NewReader("user_id=3&date=2022-07-05T15:04:01Z&title=sync&tags=Meeting, oncall&tags=meeting"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rec := httptest.NewRecorder()
+	api.Create(rec, req)
+
+	require.Len(t, store.CreateCalls(), 1)
+	assert.Equal(t, []string{"meeting", "oncall"}, store.CreateCalls()[0].E.Tags)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestCreateWithDuration(t *testing.T) {
+	api := API{}
+	store := &bolt.EventRepositoryMock{
+		CreateFunc: func(user_id uint64, e event.Event) (event.Event, error) {
+			e.ID = 1
+			return e, nil
+		},
+	}
+	api.eventStore = store
+
+	req := httptest.NewRequest("POST", "/create_event", strings.NewReader("user_id=3&date=2022-07-05T15:04:01Z&title=sync&duration=1h30m"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	api.Create(rec, req)
+
+	require.Len(t, store.CreateCalls(), 1)
+	assert.Equal(t, event.Duration(90*time.Minute), store.CreateCalls()[0].E.Duration)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"duration":"1h30m0s"`)
+
+	req = httptest.NewRequest("POST", "/create_event", strings.NewReader("user_id=3&date=2022-07-05T15:04:01Z&title=sync&duration=-1h"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	api.Create(rec, req)
+
+	assert.Len(t, store.CreateCalls(), 1)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetWithTagFilter(t *testing.T) {
+	api := API{}
+	events := []event.Event{
+		{ID: 1, Title: "standup", Date: eventTime, Tags: []string{"meeting"}},
+		{ID: 2, Title: "release 1.0", Date: eventTime, Tags: []string{"meeting", "release"}},
+		{ID: 3, Title: "night shift", Date: eventTime, Tags: []string{"oncall"}},
+	}
+
+	testCases := []struct {
+		desc      string
+		query     string
+		wantIDs   []uint64
+		wantCode  int
+		wantError string
+	}{
+		{desc: "no filter", query: "", wantIDs: []uint64{1, 2, 3}, wantCode: http.StatusOK},
+		{desc: "any", query: "&tags=release,oncall", wantIDs: []uint64{2, 3}, wantCode: http.StatusOK},
+		{desc: "all", query: "&tags=release,meeting&tags_match=all", wantIDs: []uint64{2}, wantCode: http.StatusOK},
+		{desc: "nothing matched", query: "&tags=vacation", wantCode: http.StatusNoContent},
+		{desc: "bad match mode", query: "&tags=release&tags_match=some", wantCode: http.StatusBadRequest, wantError: "can't parse tags filter"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			api.eventStore = &bolt.EventRepositoryMock{
+				GetForDayFunc: func(user_id uint64, day time.Time) ([]event.Event, error) {
+					return events, nil
+				},
+			}
+
+			req := httptest.NewRequest("GET", "/events_for_day?user_id=3&date=2022-07-05T15:04:01Z"+tC.query, nil)
+			rec := httptest.NewRecorder()
+			api.Get(rec, req)
+
+			assert.Equal(t, tC.wantCode, rec.Code)
+			switch {
+			case tC.wantError != "":
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.Equal(t, tC.wantError, jsonErr.Details)
+			case tC.wantIDs != nil:
+				got := []event.Event{}
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				ids := make([]uint64, 0, len(got))
+				for _, e := range got {
+					ids = append(ids, e.ID)
+				}
+				assert.Equal(t, tC.wantIDs, ids)
+			}
+		})
+	}
+}
+
+func TestGetForRange(t *testing.T) {
+	api := API{}
+
+	testCases := []struct {
+		desc           string
+		store          *bolt.EventRepositoryMock
+		query          string
+		checkMockCalls func(tr *bolt.EventRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "success",
+			store: &bolt.EventRepositoryMock{
+				GetForRangeFunc: func(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+					return tEventForWeek, nil
+				},
+			},
+			query: "user_id=3&from=2022-07-01T00:00:00Z&to=2022-07-08T00:00:00Z&tags=release&tags_match=all",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				require.Len(t, tr.GetForRangeCalls(), 1)
+				call := tr.GetForRangeCalls()[0]
+				assert.Equal(t, uint64(3), call.User_id)
+				assert.Equal(t, time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), call.From)
+				assert.Equal(t, time.Date(2022, 7, 8, 0, 0, 0, 0, time.UTC), call.To)
+				assert.Equal(t, event.TagFilter{Tags: []string{"release"}, MatchAll: true}, call.Filter)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := []event.Event{}
+				err := json.NewDecoder(rec.Body).Decode(&got)
+				require.NoError(t, err)
+				assert.EqualValues(t, tEventForWeek, got)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc:           "bad range",
+			store:          &bolt.EventRepositoryMock{},
+			query:          "user_id=3&from=2022-07-08T00:00:00Z&to=2022-07-01T00:00:00Z",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				err := json.NewDecoder(rec.Body).Decode(&jsonErr)
+				require.NoError(t, err)
+				assert.EqualValues(t, "bad range", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			desc:           "bad to",
+			store:          &bolt.EventRepositoryMock{},
+			query:          "user_id=3&from=2022-07-08T00:00:00Z",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				err := json.NewDecoder(rec.Body).Decode(&jsonErr)
+				require.NoError(t, err)
+				assert.EqualValues(t, "can't parse to, use RFC3339 format", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			api.eventStore = tC.store
+
+			req := httptest.NewRequest("GET", "/events_for_range?"+tC.query, nil)
+			rec := httptest.NewRecorder()
+			api.GetForRange(rec, req)
+
+			tC.checkMockCalls(tC.store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
+
+func TestGetTags(t *testing.T) {
+	api := API{}
+	tags := []event.TagCount{{Tag: "meeting", Count: 3}, {Tag: "release", Count: 1}}
+	store := &bolt.EventRepositoryMock{
+		GetTagsFunc: func(user_id uint64) ([]event.TagCount, error) {
+			return tags, nil
+		},
+	}
+	api.eventStore = store
+
+	req := httptest.NewRequest("GET", "/tags?user_id=3", nil)
+	rec := httptest.NewRecorder()
+	api.GetTags(rec, req)
+
+	got := []event.TagCount{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Equal(t, tags, got)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, store.GetTagsCalls(), 1)
+}
+
+func TestGetStats(t *testing.T) {
+	api := API{}
+	events := map[uint64][]event.Event{
+		1: {
+			{ID: 1, Title: "sync", Date: time.Date(2022, 7, 4, 10, 0, 0, 0, time.UTC), Duration: event.Duration(time.Hour), Tags: []string{"meeting"}},
+			{ID: 2, Title: "release", Date: time.Date(2022, 7, 12, 23, 30, 0, 0, time.UTC), Duration: event.Duration(2 * time.Hour), Tags: []string{"meeting", "release"}},
+		},
+		2: {
+			{ID: 1, Title: "review", Date: time.Date(2022, 7, 5, 12, 0, 0, 0, time.UTC), Duration: event.Duration(30 * time.Minute)},
+		},
+	}
+
+	testCases := []struct {
+		desc           string
+		store          *bolt.EventRepositoryMock
+		query          string
+		checkMockCalls func(tr *bolt.EventRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "by week and tag",
+			store: &bolt.EventRepositoryMock{
+				GetForRangeFunc: func(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+					return events[user_id], nil
+				},
+			},
+			query: "user_id=1,2&from=2022-07-01T00:00:00Z&to=2022-08-01T00:00:00Z&group_by=week,tag",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				require.Len(t, tr.GetForRangeCalls(), 2)
+				assert.Equal(t, uint64(1), tr.GetForRangeCalls()[0].User_id)
+				assert.Equal(t, uint64(2), tr.GetForRangeCalls()[1].User_id)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := []event.Stats{}
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				assert.Equal(t, []event.Stats{
+					{Period: "2022-W27", Count: 1, Duration: event.Duration(30 * time.Minute)},
+					{Period: "2022-W27", Tag: "meeting", Count: 1, Duration: event.Duration(time.Hour)},
+					{Period: "2022-W28", Tag: "meeting", Count: 1, Duration: event.Duration(2 * time.Hour)},
+					{Period: "2022-W28", Tag: "release", Count: 1, Duration: event.Duration(2 * time.Hour)},
+				}, got)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc: "by user and day in time zone of from",
+			store: &bolt.EventRepositoryMock{
+				GetForRangeFunc: func(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+					return events[user_id], nil
+				},
+			},
+			query: "user_id=1&user_id=2&from=2022-07-01T00:00:00%2B03:00&to=2022-08-01T00:00:00%2B03:00&group_by=user,day",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				require.Len(t, tr.GetForRangeCalls(), 2)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := []event.Stats{}
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				assert.Equal(t, []event.Stats{
+					{UserID: 1, Period: "2022-07-04", Count: 1, Duration: event.Duration(time.Hour)},
+					{UserID: 1, Period: "2022-07-13", Count: 1, Duration: event.Duration(2 * time.Hour)},
+					{UserID: 2, Period: "2022-07-05", Count: 1, Duration: event.Duration(30 * time.Minute)},
+				}, got)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc: "user without events",
+			store: &bolt.EventRepositoryMock{
+				GetForRangeFunc: func(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+					if user_id == 5 {
+						return []event.Event{}, nil
+					}
+					return events[user_id], nil
+				},
+			},
+			query: "user_id=5,2&from=2022-07-01T00:00:00Z&to=2022-08-01T00:00:00Z&group_by=user",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				assert.Len(t, tr.GetForRangeCalls(), 2)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := []event.Stats{}
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				assert.Equal(t, []event.Stats{
+					{UserID: 2, Count: 1, Duration: event.Duration(30 * time.Minute)},
+				}, got)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc: "only users without events",
+			store: &bolt.EventRepositoryMock{
+				GetForRangeFunc: func(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+					return []event.Event{}, nil
+				},
+			},
+			query: "user_id=5&from=2022-07-01T00:00:00Z&to=2022-08-01T00:00:00Z",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				assert.Len(t, tr.GetForRangeCalls(), 1)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, rec.Code)
+			},
+		},
+		{
+			desc: "unknown user",
+			store: &bolt.EventRepositoryMock{
+				GetForRangeFunc: func(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+					if user_id == 5 {
+						return nil, fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+					}
+					return events[user_id], nil
+				},
+			},
+			query: "user_id=2,5&from=2022-07-01T00:00:00Z&to=2022-08-01T00:00:00Z",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				assert.Len(t, tr.GetForRangeCalls(), 2)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "can't get events", jsonErr.Details)
+				assert.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			desc: "store error",
+			store: &bolt.EventRepositoryMock{
+				GetForRangeFunc: func(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+					return nil, fmt.Errorf("%w: user %d is deactivated", event.ErrForbidden, user_id)
+				},
+			},
+			query: "user_id=1,2&from=2022-07-01T00:00:00Z&to=2022-08-01T00:00:00Z",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				assert.Len(t, tr.GetForRangeCalls(), 1)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "can't get events", jsonErr.Details)
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			desc:           "bad group",
+			store:          &bolt.EventRepositoryMock{},
+			query:          "user_id=1&from=2022-07-01T00:00:00Z&to=2022-08-01T00:00:00Z&group_by=day,week",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "can't parse group_by", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			desc:           "no users",
+			store:          &bolt.EventRepositoryMock{},
+			query:          "from=2022-07-01T00:00:00Z&to=2022-08-01T00:00:00Z",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "can't parse user_id", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			api.eventStore = tC.store
+
+			req := httptest.NewRequest("GET", "/stats?"+tC.query, nil)
+			rec := httptest.NewRecorder()
+			api.GetStats(rec, req)
+
+			tC.checkMockCalls(tC.store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	api := API{}
+
+	testCases := []struct {
+		desc           string
+		body           string
+		checkMockCalls func(tr *bolt.EventRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "success",
+			body: "user_id=3&time_zone=Europe/Moscow&work_start=10:00&work_days=mon,tue",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				require.Len(t, tr.UpdateSettingsCalls(), 1)
+				call := tr.UpdateSettingsCalls()[0]
+				assert.Equal(t, uint64(3), call.User_id)
+				assert.Equal(t, event.UserSettings{
+					TimeZone:  "Europe/Moscow",
+					WorkStart: event.Clock(10 * time.Hour),
+					WorkEnd:   event.Clock(18 * time.Hour),
+					WorkDays:  []event.Weekday{event.Weekday(time.Monday), event.Weekday(time.Tuesday)},
+				}, call.S)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"time_zone":"Europe/Moscow","work_start":"10:00","work_end":"18:00","work_days":["monday","tuesday"]}`, rec.Body.String())
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			desc: "invalid settings",
+			body: "user_id=3&work_start=19:00",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				assert.Len(t, tr.UpdateSettingsCalls(), 0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "invalid settings", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			desc: "bad work days",
+			body: "user_id=3&work_days=someday",
+			checkMockCalls: func(tr *bolt.EventRepositoryMock) {
+				assert.Len(t, tr.UpdateSettingsCalls(), 0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "can't parse work_days", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			store := &bolt.EventRepositoryMock{
+				GetSettingsFunc: func(user_id uint64) (event.UserSettings, error) {
+					return event.DefaultUserSettings(), nil
+				},
+				UpdateSettingsFunc: func(user_id uint64, s event.UserSettings) error {
+					return nil
+				},
+			}
+			api.eventStore = store
+
+			req := httptest.NewRequest("PUT", "/update_settings", strings.NewReader(tC.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			api.UpdateSettings(rec, req)
+
+			tC.checkMockCalls(store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
+
+func TestFindSlots(t *testing.T) {
+	api := API{}
+	settings := map[uint64]event.UserSettings{
+		1: event.DefaultUserSettings(),
+		2: {
+			TimeZone:  "Europe/Moscow",
+			WorkStart: event.Clock(9 * time.Hour),
+			WorkEnd:   event.Clock(18 * time.Hour),
+			WorkDays:  []event.Weekday{event.Weekday(time.Friday)},
+		},
+	}
+	store := &bolt.EventRepositoryMock{
+		GetSettingsFunc: func(user_id uint64) (event.UserSettings, error) {
+			return settings[user_id], nil
+		},
+		GetOverlappingFunc: func(user_id uint64, from, to time.Time) ([]event.Event, error) {
+			if user_id == 3 {
+				return nil, fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+			}
+			if user_id == 2 {
+				return []event.Event{}, nil
+			}
+			return []event.Event{
+				{ID: 1, Title: "sync", Date: time.Date(2022, 7, 8, 10, 0, 0, 0, time.UTC), Duration: event.Duration(time.Hour)},
+			}, nil
+		},
+	}
+	api.eventStore = store
+
+	req := httptest.NewRequest("GET", "/find_slots?user_id=1,2&duration=1h&from=2022-07-07T00:00:00Z&to=2022-07-09T00:00:00Z", nil)
+	rec := httptest.NewRecorder()
+	api.FindSlots(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	got := []event.Interval{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	// user 2 works only on friday 06:00-15:00 UTC, user 1 on weekdays 09:00-18:00 UTC
+	want := []event.Interval{
+		{Start: time.Date(2022, 7, 8, 11, 0, 0, 0, time.UTC), End: time.Date(2022, 7, 8, 15, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2022, 7, 8, 9, 0, 0, 0, time.UTC), End: time.Date(2022, 7, 8, 10, 0, 0, 0, time.UTC)},
+	}
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.True(t, want[i].Start.Equal(got[i].Start), "slot %d start %s", i, got[i].Start)
+		assert.True(t, want[i].End.Equal(got[i].End), "slot %d end %s", i, got[i].End)
+	}
+
+	require.Len(t, store.GetOverlappingCalls(), 2)
+	assert.Equal(t, time.Date(2022, 7, 7, 0, 0, 0, 0, time.UTC), store.GetOverlappingCalls()[0].From)
+
+	req = httptest.NewRequest("GET", "/find_slots?user_id=1&from=2022-07-07T00:00:00Z&to=2022-07-09T00:00:00Z", nil)
+	rec = httptest.NewRecorder()
+	api.FindSlots(rec, req)
+
+	jsonErr := new(jsonError)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+	assert.EqualValues(t, "can't parse duration, use format like 1h30m", jsonErr.Details)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// unknown users are not taken as free
+	req = httptest.NewRequest("GET", "/find_slots?user_id=1,3&duration=1h&from=2022-07-07T00:00:00Z&to=2022-07-09T00:00:00Z", nil)
+	rec = httptest.NewRecorder()
+	api.FindSlots(rec, req)
+
+	jsonErr = new(jsonError)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+	assert.EqualValues(t, "can't get events", jsonErr.Details)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
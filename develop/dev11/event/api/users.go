@@ -0,0 +1,398 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"calendar/event"
+	"calendar/http/render"
+)
+
+// createOrgResponse is organization with its first admin
+type createOrgResponse struct {
+	Org   event.Organization `json:"org" xml:"org"`
+	Admin event.User         `json:"admin" xml:"admin"`
+}
+
+// CreateOrg creates organization and its first admin named admin_name,
+// admin_id must be an admin of another organization unless there are no organizations yet
+func (a *API) CreateOrg(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be post")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("empty name"), "no name provided")
+		return
+	}
+
+	adminName := r.FormValue("admin_name")
+	if adminName == "" {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("empty admin name"), "no admin_name provided")
+		return
+	}
+
+	orgs, err := a.userStore.ListOrgs()
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get organizations")
+		return
+	}
+	if len(orgs) > 0 {
+		if _, ok := a.getAdmin(w, r); !ok {
+			return
+		}
+	}
+
+	org, admin, err := a.userStore.CreateOrg(event.Organization{Name: name}, event.User{Name: adminName})
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't create organization")
+		return
+	}
+
+	render.Respond(w, r, http.StatusCreated, createOrgResponse{Org: org, Admin: admin})
+}
+
+// UpdateOrg renames organization, admin_id must be its admin
+func (a *API) UpdateOrg(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be put")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	oid := r.FormValue("org_id")
+	org_id, err := strconv.Atoi(oid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse org_id")
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("empty name"), "no name provided")
+		return
+	}
+
+	if !a.authorize(w, r, uint64(org_id)) {
+		return
+	}
+
+	err = a.userStore.UpdateOrg(event.Organization{ID: uint64(org_id), Name: name})
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't update organization")
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// DeleteOrg deletes organization with its users and their events, admin_id must be its admin
+func (a *API) DeleteOrg(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be delete")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	oid := r.FormValue("org_id")
+	org_id, err := strconv.Atoi(oid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse org_id")
+		return
+	}
+
+	if !a.authorize(w, r, uint64(org_id)) {
+		return
+	}
+
+	err = a.userStore.DeleteOrg(uint64(org_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't delete organization")
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// ListOrgs lists organizations admin_id can manage, that is its own organization
+func (a *API) ListOrgs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	admin, ok := a.getAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := a.userStore.GetOrg(admin.OrgID)
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get organizations")
+		return
+	}
+
+	render.Respond(w, r, http.StatusOK, []event.Organization{org})
+}
+
+// CreateUser adds user to organization, admin_id must be its admin
+func (a *API) CreateUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be post")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	oid := r.FormValue("org_id")
+	org_id, err := strconv.Atoi(oid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse org_id")
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("empty name"), "no name provided")
+		return
+	}
+
+	isAdmin := false
+	if v := r.FormValue("admin"); v != "" {
+		if isAdmin, err = strconv.ParseBool(v); err != nil {
+			render.Error(w, r, http.StatusBadRequest, err, "can't parse admin")
+			return
+		}
+	}
+
+	if !a.authorize(w, r, uint64(org_id)) {
+		return
+	}
+
+	result, err := a.userStore.CreateUser(event.User{OrgID: uint64(org_id), Name: name, Admin: isAdmin})
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't create user")
+		return
+	}
+
+	render.Respond(w, r, http.StatusCreated, result)
+}
+
+// UpdateUser renames, (de)activates or changes admin flag of user,
+// values that are not provided are kept. admin_id must be admin of user organization.
+func (a *API) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be put")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	uid := r.FormValue("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	u, err := a.userStore.GetUser(uint64(user_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get user")
+		return
+	}
+
+	if name, ok := r.Form["name"]; ok {
+		if name[0] == "" {
+			render.Error(w, r, http.StatusBadRequest, fmt.Errorf("empty name"), "no name provided")
+			return
+		}
+		u.Name = name[0]
+	}
+	if v := r.FormValue("active"); v != "" {
+		if u.Active, err = strconv.ParseBool(v); err != nil {
+			render.Error(w, r, http.StatusBadRequest, err, "can't parse active")
+			return
+		}
+	}
+	if v := r.FormValue("admin"); v != "" {
+		if u.Admin, err = strconv.ParseBool(v); err != nil {
+			render.Error(w, r, http.StatusBadRequest, err, "can't parse admin")
+			return
+		}
+	}
+
+	if !a.authorize(w, r, u.OrgID) {
+		return
+	}
+
+	err = a.userStore.UpdateUser(u)
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't update user")
+		return
+	}
+
+	render.Respond(w, r, http.StatusOK, u)
+}
+
+// DeleteUser deletes user with all events, admin_id must be admin of user organization
+func (a *API) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be delete")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse form")
+		return
+	}
+
+	uid := r.FormValue("user_id")
+	user_id, err := strconv.Atoi(uid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse user_id")
+		return
+	}
+
+	u, err := a.userStore.GetUser(uint64(user_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get user")
+		return
+	}
+
+	if !a.authorize(w, r, u.OrgID) {
+		return
+	}
+
+	err = a.userStore.DeleteUser(u.ID)
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't delete user")
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// ListUsers lists users of organization, admin_id must be its admin
+func (a *API) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		render.Error(w, r, http.StatusBadRequest, fmt.Errorf("bad method: %s", r.Method), "method should be get")
+		return
+	}
+
+	if _, err := render.Negotiate(r); err != nil {
+		render.Error(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	oid := r.URL.Query().Get("org_id")
+	org_id, err := strconv.Atoi(oid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse org_id")
+		return
+	}
+
+	if !a.authorize(w, r, uint64(org_id)) {
+		return
+	}
+
+	users, err := a.userStore.ListUsers(uint64(org_id))
+	if err != nil {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get users")
+		return
+	}
+
+	if len(users) == 0 {
+		render.NoContent(w, r)
+		return
+	}
+	render.Respond(w, r, http.StatusOK, users)
+}
+
+// authorize checks that admin_id form value is an active admin of the organization,
+// error response is written when it is not
+func (a *API) authorize(w http.ResponseWriter, r *http.Request, org_id uint64) bool {
+	admin, ok := a.getAdmin(w, r)
+	if !ok {
+		return false
+	}
+	if !admin.CanManage(org_id) {
+		err := fmt.Errorf("%w: user %d is not admin of organization %d", event.ErrForbidden, admin.ID, org_id)
+		render.Error(w, r, http.StatusForbidden, err, "not allowed")
+		return false
+	}
+
+	return true
+}
+
+// getAdmin returns user of admin_id form value when it is an active admin of its organization,
+// error response is written when it is not
+func (a *API) getAdmin(w http.ResponseWriter, r *http.Request) (event.User, bool) {
+	aid := r.FormValue("admin_id")
+	admin_id, err := strconv.Atoi(aid)
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err, "can't parse admin_id")
+		return event.User{}, false
+	}
+
+	admin, err := a.userStore.GetUser(uint64(admin_id))
+	if err != nil && !errors.Is(err, event.ErrNotFound) {
+		render.Error(w, r, event.GetStatusCode(err), err, "can't get admin")
+		return event.User{}, false
+	}
+	if err != nil || !admin.CanManage(admin.OrgID) {
+		err = fmt.Errorf("%w: user %d is not admin", event.ErrForbidden, admin_id)
+		render.Error(w, r, http.StatusForbidden, err, "not allowed")
+		return event.User{}, false
+	}
+
+	return admin, true
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"calendar/event"
+	"calendar/event/repository/bolt"
+)
+
+func TestCreateUser(t *testing.T) {
+	api := API{}
+	users := map[uint64]event.User{
+		1: {ID: 1, OrgID: 1, Name: "alice", Admin: true, Active: true},
+		2: {ID: 2, OrgID: 1, Name: "bob", Active: true},
+		3: {ID: 3, OrgID: 1, Name: "carol", Admin: true},
+	}
+
+	testCases := []struct {
+		desc           string
+		body           string
+		checkMockCalls func(tr *bolt.UserRepositoryMock)
+		checkResponse  func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "success",
+			body: "org_id=1&admin_id=1&name=dave&admin=true",
+			checkMockCalls: func(tr *bolt.UserRepositoryMock) {
+				require.Len(t, tr.CreateUserCalls(), 1)
+				assert.Equal(t, event.User{OrgID: 1, Name: "dave", Admin: true}, tr.CreateUserCalls()[0].U)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				got := event.User{}
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				assert.Equal(t, uint64(4), got.ID)
+				assert.Equal(t, http.StatusCreated, rec.Code)
+			},
+		},
+		{
+			desc: "not admin",
+			body: "org_id=1&admin_id=2&name=dave",
+			checkMockCalls: func(tr *bolt.UserRepositoryMock) {
+				assert.Len(t, tr.CreateUserCalls(), 0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "not allowed", jsonErr.Details)
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			desc: "deactivated admin",
+			body: "org_id=1&admin_id=3&name=dave",
+			checkMockCalls: func(tr *bolt.UserRepositoryMock) {
+				assert.Len(t, tr.CreateUserCalls(), 0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			desc: "unknown admin",
+			body: "org_id=1&admin_id=9&name=dave",
+			checkMockCalls: func(tr *bolt.UserRepositoryMock) {
+				assert.Len(t, tr.CreateUserCalls(), 0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			desc: "no name",
+			body: "org_id=1&admin_id=1",
+			checkMockCalls: func(tr *bolt.UserRepositoryMock) {
+				assert.Len(t, tr.GetUserCalls(), 0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				jsonErr := new(jsonError)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&jsonErr))
+				assert.EqualValues(t, "no name provided", jsonErr.Details)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			store := &bolt.UserRepositoryMock{
+				GetUserFunc: func(user_id uint64) (event.User, error) {
+					u, ok := users[user_id]
+					if !ok {
+						return event.User{}, fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+					}
+					return u, nil
+				},
+				CreateUserFunc: func(u event.User) (event.User, error) {
+					u.ID = 4
+					u.Active = true
+					return u, nil
+				},
+			}
+			api.userStore = store
+
+			req := httptest.NewRequest("POST", "/create_user", strings.NewReader(tC.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			api.CreateUser(rec, req)
+
+			tC.checkMockCalls(store)
+
+			tC.checkResponse(rec)
+		})
+	}
+}
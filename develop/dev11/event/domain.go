@@ -6,15 +6,78 @@ import (
 	"time"
 )
 
+// Organization groups users, it is managed by its admins
+type Organization struct {
+	ID        uint64    `json:"id,omitempty" xml:"id,omitempty"`
+	Name      string    `json:"name,omitempty" xml:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+}
+
+// User belongs to one organization, deactivated users can't access their events
 type User struct {
-	ID     uint64  `json:"id,omitempty"`
-	Events []Event `json:"events,omitempty"`
+	ID        uint64    `json:"id,omitempty" xml:"id,omitempty"`
+	OrgID     uint64    `json:"org_id,omitempty" xml:"org_id,omitempty"`
+	Name      string    `json:"name,omitempty" xml:"name,omitempty"`
+	Admin     bool      `json:"admin" xml:"admin"`
+	Active    bool      `json:"active" xml:"active"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	Events    []Event   `json:"events,omitempty" xml:"event,omitempty"`
+}
+
+// CanManage reports whether user is an active admin of the organization
+func (u User) CanManage(org_id uint64) bool {
+	return u.Active && u.Admin && u.OrgID == org_id
 }
 
 type Event struct {
-	ID    uint64    `json:"id,omitempty"`
-	Title string    `json:"title,omitempty"`
-	Date  time.Time `json:"date,omitempty"`
+	ID       uint64    `json:"id,omitempty" xml:"id,omitempty"`
+	Title    string    `json:"title,omitempty" xml:"title,omitempty"`
+	Date     time.Time `json:"date,omitempty" xml:"date"`
+	Duration Duration  `json:"duration,omitempty" xml:"duration,omitempty"`
+	Tags     []string  `json:"tags,omitempty" xml:"tag,omitempty"`
+}
+
+// End returns time when event finishes
+func (e Event) End() time.Time {
+	return e.Date.Add(time.Duration(e.Duration))
+}
+
+// Duration is time.Duration encoded as text like "1h30m"
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// TrashedEvent is an event that was deleted and can still be restored
+type TrashedEvent struct {
+	Event
+	DeletedAt time.Time `json:"deleted_at" xml:"deleted_at"`
+}
+
+// Actions stored in event history
+const (
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// EventVersion is a previous version of an event saved before it was changed
+type EventVersion struct {
+	Version   uint64    `json:"version" xml:"version"`
+	Action    string    `json:"action" xml:"action"`
+	ChangedAt time.Time `json:"changed_at" xml:"changed_at"`
+	Event     Event     `json:"event" xml:"event"`
 }
 
 type EventRepository interface {
@@ -24,17 +87,48 @@ type EventRepository interface {
 	GetForDay(user_id uint64, day time.Time) ([]Event, error)
 	GetForWeek(user_id uint64, week time.Time) ([]Event, error)
 	GetForMonth(user_id uint64, month time.Time) ([]Event, error)
+	GetForRange(user_id uint64, from, to time.Time, filter TagFilter) ([]Event, error)
+	GetOverlapping(user_id uint64, from, to time.Time) ([]Event, error)
+	GetTags(user_id uint64) ([]TagCount, error)
+	Restore(user_id uint64, event_id uint64) (Event, error)
+	GetTrash(user_id uint64) ([]TrashedEvent, error)
+	GetHistory(user_id uint64, event_id uint64) ([]EventVersion, error)
+	PurgeTrash(before time.Time) (int, error)
+	GetSettings(user_id uint64) (UserSettings, error)
+	UpdateSettings(user_id uint64, s UserSettings) error
+}
+
+// UserRepository stores organizations and their users,
+// deleting organization or user deletes their events as well
+type UserRepository interface {
+	CreateOrg(o Organization, admin User) (Organization, User, error)
+	UpdateOrg(o Organization) error
+	DeleteOrg(org_id uint64) error
+	GetOrg(org_id uint64) (Organization, error)
+	ListOrgs() ([]Organization, error)
+	CreateUser(u User) (User, error)
+	UpdateUser(u User) error
+	DeleteUser(user_id uint64) error
+	GetUser(user_id uint64) (User, error)
+	ListUsers(org_id uint64) ([]User, error)
 }
 
 var (
 	ErrNotFound            = errors.New("your requested item is not found")
+	ErrForbidden           = errors.New("operation is not allowed")
+	ErrConflict            = errors.New("operation conflicts with current state")
 	ErrInternalServerError = errors.New("internal server error")
 )
 
 // GetStatusCode gets http code from error
 func GetStatusCode(err error) int {
-	if errors.Is(err, ErrNotFound) {
+	switch {
+	case errors.Is(err, ErrNotFound):
 		return http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
 	}
 
 	return http.StatusInternalServerError
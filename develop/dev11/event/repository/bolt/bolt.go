@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"go.etcd.io/bbolt"
@@ -12,28 +13,19 @@ import (
 )
 
 type boltEventRepository struct {
-	db *bbolt.DB
+	db *DB
 }
 
-func NewBoltEventRepository(db *bbolt.DB) event.EventRepository {
+func NewBoltEventRepository(db *DB) event.EventRepository {
 	return &boltEventRepository{
 		db: db,
 	}
 }
 
-func NewBoltDB(path string) (*bbolt.DB, error) {
-	db, err := bbolt.Open(path, 0666, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return db, nil
-}
-
 func (b *boltEventRepository) Create(user_id uint64, e event.Event) (event.Event, error) {
 	var result event.Event
 	err := b.db.Update(func(tx *bbolt.Tx) error {
-		user, err := tx.CreateBucketIfNotExists(itob(user_id))
+		user, err := userBucket(tx, user_id)
 		if err != nil {
 			return err
 		}
@@ -52,6 +44,9 @@ func (b *boltEventRepository) Create(user_id uint64, e event.Event) (event.Event
 		} else if err := eBkt.Put(itob(e.ID), buf); err != nil {
 			return err
 		}
+		if err := indexTags(user, e); err != nil {
+			return err
+		}
 		result = e
 
 		return nil
@@ -66,9 +61,9 @@ func (b *boltEventRepository) Create(user_id uint64, e event.Event) (event.Event
 
 func (b *boltEventRepository) Update(user_id uint64, e event.Event) error {
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		user := tx.Bucket(itob(user_id))
-		if user == nil {
-			return fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
 		}
 
 		eBkt := user.Bucket([]byte("events"))
@@ -81,6 +76,21 @@ func (b *boltEventRepository) Update(user_id uint64, e event.Event) error {
 			return fmt.Errorf("%w: user %d has no %d event", event.ErrNotFound, user_id, e.ID)
 		}
 
+		if err := saveVersion(user, v, event.ActionUpdate); err != nil {
+			return err
+		}
+
+		var old event.Event
+		if err := json.Unmarshal(v, &old); err != nil {
+			return err
+		}
+		if err := unindexTags(user, old); err != nil {
+			return err
+		}
+		if err := indexTags(user, e); err != nil {
+			return err
+		}
+
 		buf, err := json.Marshal(e)
 		if err != nil {
 			return fmt.Errorf("%w: %s", event.ErrInternalServerError, err.Error())
@@ -92,9 +102,9 @@ func (b *boltEventRepository) Update(user_id uint64, e event.Event) error {
 
 func (b *boltEventRepository) Delete(user_id uint64, event_id uint64) error {
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		user := tx.Bucket(itob(user_id))
-		if user == nil {
-			return fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
 		}
 
 		eBkt := user.Bucket([]byte("events"))
@@ -107,21 +117,357 @@ func (b *boltEventRepository) Delete(user_id uint64, event_id uint64) error {
 			return fmt.Errorf("%w: user %d has no %d event", event.ErrNotFound, user_id, event_id)
 		}
 
+		if err := saveVersion(user, v, event.ActionDelete); err != nil {
+			return err
+		}
+
+		var te event.TrashedEvent
+		if err := json.Unmarshal(v, &te.Event); err != nil {
+			return err
+		}
+		te.DeletedAt = time.Now()
+		if err := unindexTags(user, te.Event); err != nil {
+			return err
+		}
+
+		tBkt, err := user.CreateBucketIfNotExists([]byte("trash"))
+		if err != nil {
+			return err
+		}
+		if buf, err := json.Marshal(te); err != nil {
+			return err
+		} else if err := tBkt.Put(itob(event_id), buf); err != nil {
+			return err
+		}
+
 		return eBkt.Delete(itob(event_id))
 	})
 }
 
+// GetForRange returns events with date in [from, to) that pass the filter,
+// tag index is used to select events when filter is not empty
+func (b *boltEventRepository) GetForRange(user_id uint64, from, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+	events := make([]event.Event, 0)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		eBkt := user.Bucket([]byte("events"))
+		if eBkt == nil {
+			return nil
+		}
+
+		inRange := func(v []byte) error {
+			var ev event.Event
+			if err := json.Unmarshal(v, &ev); err != nil {
+				return err
+			}
+			if !ev.Date.Before(from) && ev.Date.Before(to) {
+				events = append(events, ev)
+			}
+			return nil
+		}
+
+		if filter.IsEmpty() {
+			return eBkt.ForEach(func(k, v []byte) error {
+				return inRange(v)
+			})
+		}
+
+		for _, id := range taggedEvents(user, filter) {
+			v := eBkt.Get(itob(id))
+			if v == nil {
+				continue
+			}
+			if err := inRange(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetOverlapping returns events that take time in [from, to), events that started
+// before from are included while they last, events without duration take no time
+// and are not returned
+func (b *boltEventRepository) GetOverlapping(user_id uint64, from, to time.Time) ([]event.Event, error) {
+	events := make([]event.Event, 0)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		eBkt := user.Bucket([]byte("events"))
+		if eBkt == nil {
+			return nil
+		}
+
+		return eBkt.ForEach(func(k, v []byte) error {
+			var ev event.Event
+			if err := json.Unmarshal(v, &ev); err != nil {
+				return err
+			}
+			if ev.Duration > 0 && ev.Date.Before(to) && ev.End().After(from) {
+				events = append(events, ev)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetTags returns user tags with number of events, the most used tags go first
+func (b *boltEventRepository) GetTags(user_id uint64) ([]event.TagCount, error) {
+	tags := make([]event.TagCount, 0)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		tBkt := user.Bucket([]byte("tags"))
+		if tBkt == nil {
+			return nil
+		}
+
+		return tBkt.ForEach(func(k, v []byte) error {
+			tagBkt := tBkt.Bucket(k)
+			if tagBkt == nil {
+				return nil
+			}
+			tags = append(tags, event.TagCount{Tag: string(k), Count: tagBkt.Stats().KeyN})
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Count > tags[j].Count
+	})
+	return tags, nil
+}
+
+func (b *boltEventRepository) Restore(user_id uint64, event_id uint64) (event.Event, error) {
+	var result event.Event
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		tBkt := user.Bucket([]byte("trash"))
+		if tBkt == nil {
+			return fmt.Errorf("%w: user %d has no deleted events", event.ErrNotFound, user_id)
+		}
+
+		v := tBkt.Get(itob(event_id))
+		if v == nil {
+			return fmt.Errorf("%w: user %d has no %d event in trash", event.ErrNotFound, user_id, event_id)
+		}
+
+		var te event.TrashedEvent
+		if err := json.Unmarshal(v, &te); err != nil {
+			return err
+		}
+
+		eBkt, err := user.CreateBucketIfNotExists([]byte("events"))
+		if err != nil {
+			return err
+		}
+		if buf, err := json.Marshal(te.Event); err != nil {
+			return err
+		} else if err := eBkt.Put(itob(event_id), buf); err != nil {
+			return err
+		}
+		if err := indexTags(user, te.Event); err != nil {
+			return err
+		}
+		result = te.Event
+
+		return tBkt.Delete(itob(event_id))
+	})
+
+	if err != nil {
+		return event.Event{}, err
+	}
+
+	return result, nil
+}
+
+func (b *boltEventRepository) GetTrash(user_id uint64) ([]event.TrashedEvent, error) {
+	events := make([]event.TrashedEvent, 0)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		tBkt := user.Bucket([]byte("trash"))
+		if tBkt == nil {
+			return nil
+		}
+
+		return tBkt.ForEach(func(k, v []byte) error {
+			var te event.TrashedEvent
+			if err := json.Unmarshal(v, &te); err != nil {
+				return err
+			}
+			events = append(events, te)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (b *boltEventRepository) GetHistory(user_id uint64, event_id uint64) ([]event.EventVersion, error) {
+	versions := make([]event.EventVersion, 0)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		hBkt := user.Bucket([]byte("history"))
+		if hBkt == nil {
+			return nil
+		}
+
+		evBkt := hBkt.Bucket(itob(event_id))
+		if evBkt == nil {
+			return nil
+		}
+
+		return evBkt.ForEach(func(k, v []byte) error {
+			var ver event.EventVersion
+			if err := json.Unmarshal(v, &ver); err != nil {
+				return err
+			}
+			versions = append(versions, ver)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// PurgeTrash permanently removes events deleted before the given time
+// along with their history and returns the number of purged events.
+func (b *boltEventRepository) PurgeTrash(before time.Time) (int, error) {
+	purged := 0
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, user *bbolt.Bucket) error {
+			tBkt := user.Bucket([]byte("trash"))
+			if tBkt == nil {
+				return nil
+			}
+
+			expired := make([][]byte, 0)
+			err := tBkt.ForEach(func(k, v []byte) error {
+				var te event.TrashedEvent
+				if err := json.Unmarshal(v, &te); err != nil {
+					return err
+				}
+				if te.DeletedAt.Before(before) {
+					expired = append(expired, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			hBkt := user.Bucket([]byte("history"))
+			for _, k := range expired {
+				if err := tBkt.Delete(k); err != nil {
+					return err
+				}
+				if hBkt != nil && hBkt.Bucket(k) != nil {
+					if err := hBkt.DeleteBucket(k); err != nil {
+						return err
+					}
+				}
+				purged++
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// GetSettings returns user settings, default settings are returned when user has not set them
+func (b *boltEventRepository) GetSettings(user_id uint64) (event.UserSettings, error) {
+	result := event.DefaultUserSettings()
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		v := user.Get([]byte("settings"))
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &result)
+	})
+
+	if err != nil {
+		return event.UserSettings{}, err
+	}
+	return result, nil
+}
+
+func (b *boltEventRepository) UpdateSettings(user_id uint64, s event.UserSettings) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		buf, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		return user.Put([]byte("settings"), buf)
+	})
+}
+
 func (b *boltEventRepository) GetForDay(user_id uint64, day time.Time) ([]event.Event, error) {
 	events := make([]event.Event, 0)
 	err := b.db.View(func(tx *bbolt.Tx) error {
-		user := tx.Bucket(itob(user_id))
-		if user == nil {
-			return fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
 		}
 
 		eBkt := user.Bucket([]byte("events"))
 		if eBkt == nil {
-			return fmt.Errorf("%w: user %d has no events", event.ErrNotFound, user_id)
+			return nil
 		}
 		c := eBkt.Cursor()
 
@@ -148,14 +494,14 @@ func (b *boltEventRepository) GetForDay(user_id uint64, day time.Time) ([]event.
 func (b *boltEventRepository) GetForWeek(user_id uint64, week time.Time) ([]event.Event, error) {
 	events := make([]event.Event, 0)
 	err := b.db.View(func(tx *bbolt.Tx) error {
-		user := tx.Bucket(itob(user_id))
-		if user == nil {
-			return fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
 		}
 
 		eBkt := user.Bucket([]byte("events"))
 		if eBkt == nil {
-			return fmt.Errorf("%w: user %d has no events", event.ErrNotFound, user_id)
+			return nil
 		}
 		c := eBkt.Cursor()
 
@@ -183,14 +529,14 @@ func (b *boltEventRepository) GetForWeek(user_id uint64, week time.Time) ([]even
 func (b *boltEventRepository) GetForMonth(user_id uint64, month time.Time) ([]event.Event, error) {
 	events := make([]event.Event, 0)
 	err := b.db.View(func(tx *bbolt.Tx) error {
-		user := tx.Bucket(itob(user_id))
-		if user == nil {
-			return fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+		user, err := userBucket(tx, user_id)
+		if err != nil {
+			return err
 		}
 
 		eBkt := user.Bucket([]byte("events"))
 		if eBkt == nil {
-			return fmt.Errorf("%w: user %d has no events", event.ErrNotFound, user_id)
+			return nil
 		}
 		c := eBkt.Cursor()
 
@@ -217,6 +563,120 @@ func (b *boltEventRepository) GetForMonth(user_id uint64, month time.Time) ([]ev
 	return events, nil
 }
 
+// indexTags adds event to tag index of the user bucket
+func indexTags(user *bbolt.Bucket, e event.Event) error {
+	if len(e.Tags) == 0 {
+		return nil
+	}
+
+	tBkt, err := user.CreateBucketIfNotExists([]byte("tags"))
+	if err != nil {
+		return err
+	}
+	for _, tag := range e.Tags {
+		tagBkt, err := tBkt.CreateBucketIfNotExists([]byte(tag))
+		if err != nil {
+			return err
+		}
+		if err := tagBkt.Put(itob(e.ID), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unindexTags removes event from tag index of the user bucket, tags without events are dropped
+func unindexTags(user *bbolt.Bucket, e event.Event) error {
+	tBkt := user.Bucket([]byte("tags"))
+	if tBkt == nil {
+		return nil
+	}
+
+	for _, tag := range e.Tags {
+		tagBkt := tBkt.Bucket([]byte(tag))
+		if tagBkt == nil {
+			continue
+		}
+		if err := tagBkt.Delete(itob(e.ID)); err != nil {
+			return err
+		}
+		if k, _ := tagBkt.Cursor().First(); k == nil {
+			if err := tBkt.DeleteBucket([]byte(tag)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// taggedEvents returns sorted ids of events matching the filter from tag index
+func taggedEvents(user *bbolt.Bucket, filter event.TagFilter) []uint64 {
+	tBkt := user.Bucket([]byte("tags"))
+	if tBkt == nil {
+		return nil
+	}
+
+	tags := event.NormalizeTags(filter.Tags)
+	counts := make(map[uint64]int)
+	for _, tag := range tags {
+		tagBkt := tBkt.Bucket([]byte(tag))
+		if tagBkt == nil {
+			continue
+		}
+		tagBkt.ForEach(func(k, v []byte) error {
+			counts[binary.BigEndian.Uint64(k)]++
+			return nil
+		})
+	}
+
+	ids := make([]uint64, 0, len(counts))
+	for id, n := range counts {
+		if filter.MatchAll && n < len(tags) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// saveVersion appends raw event value v to the event history of the user bucket
+func saveVersion(user *bbolt.Bucket, v []byte, action string) error {
+	var e event.Event
+	if err := json.Unmarshal(v, &e); err != nil {
+		return err
+	}
+
+	hBkt, err := user.CreateBucketIfNotExists([]byte("history"))
+	if err != nil {
+		return err
+	}
+	evBkt, err := hBkt.CreateBucketIfNotExists(itob(e.ID))
+	if err != nil {
+		return err
+	}
+	version, err := evBkt.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	ver := event.EventVersion{
+		Version:   version,
+		Action:    action,
+		ChangedAt: time.Now(),
+		Event:     e,
+	}
+	buf, err := json.Marshal(ver)
+	if err != nil {
+		return err
+	}
+
+	return evBkt.Put(itob(version), buf)
+}
+
 // itob returns an 8-byte big endian representation of v.
 func itob(v uint64) []byte {
 	b := make([]byte, 8)
@@ -0,0 +1,98 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	"calendar/event"
+)
+
+// newTestUser creates organization in db and returns its admin
+func newTestUser(t *testing.T, db *DB) event.User {
+	t.Helper()
+
+	_, admin, err := NewBoltUserRepository(db).CreateOrg(event.Organization{Name: "acme"}, event.User{Name: "alice"})
+	require.NoError(t, err)
+	return admin
+}
+
+func eventTitles(events []event.Event) []string {
+	titles := make([]string, 0, len(events))
+	for _, e := range events {
+		titles = append(titles, e.Title)
+	}
+	return titles
+}
+
+func TestGetOverlapping(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db)
+	store := NewBoltEventRepository(db)
+
+	day := time.Date(2022, 7, 8, 0, 0, 0, 0, time.UTC)
+	for _, e := range []event.Event{
+		{Title: "vacation", Date: day.AddDate(0, 0, -3), Duration: event.Duration(5 * 24 * time.Hour)},
+		{Title: "ended", Date: day.AddDate(0, 0, -3), Duration: event.Duration(3 * 24 * time.Hour)},
+		{Title: "overnight", Date: day.Add(-2 * time.Hour), Duration: event.Duration(3 * time.Hour)},
+		{Title: "sync", Date: day.Add(10 * time.Hour), Duration: event.Duration(time.Hour)},
+		{Title: "reminder", Date: day.Add(12 * time.Hour)},
+		{Title: "tomorrow", Date: day.AddDate(0, 0, 1), Duration: event.Duration(time.Hour)},
+	} {
+		_, err := store.Create(user.ID, e)
+		require.NoError(t, err)
+	}
+
+	events, err := store.GetOverlapping(user.ID, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vacation", "overnight", "sync"}, eventTitles(events))
+
+	_, err = store.GetOverlapping(user.ID+1, day, day.AddDate(0, 0, 1))
+	assert.ErrorIs(t, err, event.ErrNotFound)
+}
+
+func TestNewUserHasNoEvents(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db)
+	store := NewBoltEventRepository(db)
+	day := time.Date(2022, 7, 8, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		desc string
+		get  func(user_id uint64) ([]event.Event, error)
+	}{
+		{desc: "day", get: func(user_id uint64) ([]event.Event, error) { return store.GetForDay(user_id, day) }},
+		{desc: "week", get: func(user_id uint64) ([]event.Event, error) { return store.GetForWeek(user_id, day) }},
+		{desc: "month", get: func(user_id uint64) ([]event.Event, error) { return store.GetForMonth(user_id, day) }},
+		{desc: "range", get: func(user_id uint64) ([]event.Event, error) {
+			return store.GetForRange(user_id, day, day.AddDate(0, 0, 1), event.TagFilter{})
+		}},
+		{desc: "overlapping", get: func(user_id uint64) ([]event.Event, error) {
+			return store.GetOverlapping(user_id, day, day.AddDate(0, 0, 1))
+		}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			events, err := tC.get(user.ID)
+			require.NoError(t, err)
+			assert.NotNil(t, events)
+			assert.Empty(t, events)
+
+			_, err = tC.get(user.ID + 1)
+			assert.ErrorIs(t, err, event.ErrNotFound)
+		})
+	}
+
+	// users created before events bucket was created with user have no events too
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(itob(user.ID)).DeleteBucket(eventsKey)
+	}))
+	for _, tC := range testCases {
+		events, err := tC.get(user.ID)
+		require.NoError(t, err, tC.desc)
+		assert.Empty(t, events, tC.desc)
+	}
+}
@@ -0,0 +1,279 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// compactTxMaxSize limits the size of a single transaction during compaction
+const compactTxMaxSize = 64 * 1024
+
+// DB wraps bbolt database, so the underlying file can be
+// compacted and replaced while the app is running
+type DB struct {
+	// mu is held exclusively only while the file is replaced
+	mu sync.RWMutex
+	// writeMu is held exclusively while compaction copies data,
+	// so writes made during the copy are not lost
+	writeMu sync.RWMutex
+	db      *bbolt.DB
+	path    string
+}
+
+func NewBoltDB(path string) (*DB, error) {
+	db, err := bbolt.Open(path, 0666, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{db: db, path: path}, nil
+}
+
+// NewBoltDBWithTimeout opens database for writing, it fails after timeout
+// if the file is locked by a running server
+func NewBoltDBWithTimeout(path string, timeout time.Duration) (*DB, error) {
+	db, err := bbolt.Open(path, 0666, &bbolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{db: db, path: path}, nil
+}
+
+// NewReadOnlyBoltDB opens database in read-only mode, it fails after timeout
+// if the file is locked by a running server
+func NewReadOnlyBoltDB(path string, timeout time.Duration) (*DB, error) {
+	db, err := bbolt.Open(path, 0666, &bbolt.Options{ReadOnly: true, Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{db: db, path: path}, nil
+}
+
+// View executes a function within a read-only transaction
+func (d *DB) View(fn func(*bbolt.Tx) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.View(fn)
+}
+
+// Update executes a function within a read-write transaction
+func (d *DB) Update(fn func(*bbolt.Tx) error) error {
+	d.writeMu.RLock()
+	defer d.writeMu.RUnlock()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.Update(fn)
+}
+
+// Path returns path to the database file
+func (d *DB) Path() string {
+	return d.path
+}
+
+// Close releases all database resources
+func (d *DB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.db.Close()
+}
+
+// Size returns current size of the database file in bytes
+func (d *DB) Size() (int64, error) {
+	var size int64
+	err := d.View(func(tx *bbolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return size, err
+}
+
+// Backup writes consistent snapshot of the database to w,
+// writers are not blocked while backup is running
+func (d *DB) Backup(w io.Writer) (int64, error) {
+	var n int64
+	err := d.View(func(tx *bbolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// Compact rewrites database into a new file to reclaim space freed by deletes
+// and replaces the current file with it. Data is copied in a read transaction,
+// reads go on and writes wait until the copy is done, both wait only while files are swapped.
+func (d *DB) Compact() (before, after int64, err error) {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+
+	tmpPath := d.path + ".compact"
+	d.mu.RLock()
+	err = compactFile(d.db, tmpPath)
+	d.mu.RUnlock()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	before, err = fileSize(d.path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, 0, err
+	}
+	after, err = fileSize(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, 0, err
+	}
+
+	if err := d.replace(tmpPath); err != nil {
+		return 0, 0, err
+	}
+	return before, after, nil
+}
+
+// replace swaps database file with the one at newPath and opens it. The current file
+// is kept until the new one is opened, so the app keeps working on it when swap fails.
+func (d *DB) replace(newPath string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.db.Close(); err != nil {
+		os.Remove(newPath)
+		return err
+	}
+
+	oldPath := d.path + ".old"
+	if err := os.Rename(d.path, oldPath); err != nil {
+		os.Remove(newPath)
+		return d.reopen(err)
+	}
+	if err := os.Rename(newPath, d.path); err != nil {
+		os.Remove(newPath)
+		return d.restore(oldPath, err)
+	}
+
+	db, err := bbolt.Open(d.path, 0666, nil)
+	if err != nil {
+		return d.restore(oldPath, fmt.Errorf("can't open new database: %w", err))
+	}
+	d.db = db
+	os.Remove(oldPath)
+	return nil
+}
+
+// restore moves the file at oldPath back and reopens it after swap failed with cause
+func (d *DB) restore(oldPath string, cause error) error {
+	if err := os.Rename(oldPath, d.path); err != nil {
+		return fmt.Errorf("%v, can't restore database: %w", cause, err)
+	}
+	return d.reopen(cause)
+}
+
+// reopen opens database file again after swap failed with cause, cause is returned
+// when the file is opened
+func (d *DB) reopen(cause error) error {
+	db, err := bbolt.Open(d.path, 0666, nil)
+	if err != nil {
+		return fmt.Errorf("%v, can't reopen database: %w", cause, err)
+	}
+	d.db = db
+	return cause
+}
+
+// CompactFile compacts database at src path into dst path, database must not be in use
+func CompactFile(src, dst string) error {
+	db, err := bbolt.Open(src, 0666, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return compactFile(db, dst)
+}
+
+// RestoreFile replaces database at path with a backup read from r,
+// the backup is verified before the current file is replaced. The current file
+// is locked while it is replaced, restore fails after timeout if a running server
+// holds the lock, the server would keep writing to the replaced file.
+func RestoreFile(r io.Reader, path string, timeout time.Duration) error {
+	cur, err := bbolt.Open(path, 0666, &bbolt.Options{Timeout: timeout})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return fmt.Errorf("database is in use: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+
+	tmpPath := path + ".restore"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := verifyFile(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("backup is corrupted: %w", err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func compactFile(src *bbolt.DB, dstPath string) error {
+	dst, err := bbolt.Open(dstPath, 0666, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := bbolt.Compact(dst, src, compactTxMaxSize); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return err
+	}
+
+	return dst.Close()
+}
+
+// verifyFile opens database file and runs consistency check on it
+func verifyFile(path string) error {
+	db, err := bbolt.Open(path, 0666, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bbolt.Tx) error {
+		var first error
+		for err := range tx.Check() {
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,206 @@
+package bolt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+// newTestDB opens database in a temp dir, it is closed when test ends
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := NewBoltDB(filepath.Join(t.TempDir(), "calendar.bdb"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func putValue(t *testing.T, db *DB, bucket, key, value string) {
+	t.Helper()
+
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(key), []byte(value))
+	}))
+}
+
+func getValue(t *testing.T, db *DB, bucket, key string) string {
+	t.Helper()
+
+	var value string
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		if bkt := tx.Bucket([]byte(bucket)); bkt != nil {
+			value = string(bkt.Get([]byte(key)))
+		}
+		return nil
+	}))
+	return value
+}
+
+func TestBackupRestore(t *testing.T) {
+	src := newTestDB(t)
+	putValue(t, src, "b", "k", "backed up")
+
+	var backup bytes.Buffer
+	n, err := src.Backup(&backup)
+	require.NoError(t, err)
+	assert.Equal(t, int64(backup.Len()), n)
+
+	// writes after backup are not restored
+	putValue(t, src, "b", "k", "changed")
+
+	path := filepath.Join(t.TempDir(), "restored.bdb")
+	require.NoError(t, RestoreFile(&backup, path, time.Second))
+
+	db, err := NewBoltDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, "backed up", getValue(t, db, "b", "k"))
+}
+
+func TestRestoreFileReplacesDatabase(t *testing.T) {
+	src := newTestDB(t)
+	putValue(t, src, "b", "k", "backed up")
+	var backup bytes.Buffer
+	_, err := src.Backup(&backup)
+	require.NoError(t, err)
+
+	dst := newTestDB(t)
+	putValue(t, dst, "b", "k", "current")
+	putValue(t, dst, "other", "k", "current")
+	path := dst.Path()
+	require.NoError(t, dst.Close())
+
+	require.NoError(t, RestoreFile(&backup, path, time.Second))
+
+	db, err := NewBoltDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, "backed up", getValue(t, db, "b", "k"))
+	assert.Equal(t, "", getValue(t, db, "other", "k"))
+}
+
+func TestRestoreFileLockedDatabase(t *testing.T) {
+	src := newTestDB(t)
+	putValue(t, src, "b", "k", "backed up")
+	var backup bytes.Buffer
+	_, err := src.Backup(&backup)
+	require.NoError(t, err)
+
+	// database opened by a running server keeps its data
+	db := newTestDB(t)
+	putValue(t, db, "b", "k", "current")
+
+	err = RestoreFile(&backup, db.Path(), 50*time.Millisecond)
+	assert.ErrorIs(t, err, bbolt.ErrTimeout)
+	assert.NoFileExists(t, db.Path()+".restore")
+
+	putValue(t, db, "b", "k2", "written")
+	require.NoError(t, db.Close())
+
+	db, err = NewBoltDB(db.Path())
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, "current", getValue(t, db, "b", "k"))
+	assert.Equal(t, "written", getValue(t, db, "b", "k2"))
+}
+
+func TestRestoreFileCorruptedBackup(t *testing.T) {
+	db := newTestDB(t)
+	putValue(t, db, "b", "k", "current")
+	path := db.Path()
+	require.NoError(t, db.Close())
+
+	err := RestoreFile(strings.NewReader("not a database"), path, time.Second)
+	assert.ErrorContains(t, err, "backup is corrupted")
+	assert.NoFileExists(t, path+".restore")
+
+	db, err = NewBoltDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, "current", getValue(t, db, "b", "k"))
+}
+
+func TestCompact(t *testing.T) {
+	db := newTestDB(t)
+	value := strings.Repeat("x", 1024)
+	for i := 0; i < 1000; i++ {
+		putValue(t, db, "b", string(itob(uint64(i))), value)
+	}
+	putValue(t, db, "kept", "k", "kept")
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket([]byte("b"))
+	}))
+
+	before, after, err := db.Compact()
+	require.NoError(t, err)
+	assert.Less(t, after, before)
+	assert.NoFileExists(t, db.Path()+".compact")
+
+	// database is reopened and keeps working after compaction
+	assert.Equal(t, "kept", getValue(t, db, "kept", "k"))
+	putValue(t, db, "kept", "k2", "written")
+	assert.Equal(t, "written", getValue(t, db, "kept", "k2"))
+}
+
+func TestCompactKeepsReadsAndWrites(t *testing.T) {
+	db := newTestDB(t)
+	putValue(t, db, "b", "k", "before")
+
+	// a write waits for compaction copy and is kept in the compacted file
+	db.writeMu.Lock()
+	written := make(chan error)
+	go func() {
+		written <- db.Update(func(tx *bbolt.Tx) error {
+			return tx.Bucket([]byte("b")).Put([]byte("k"), []byte("during"))
+		})
+	}()
+	assert.Equal(t, "before", getValue(t, db, "b", "k"), "reads are not blocked by the copy")
+	db.writeMu.Unlock()
+	require.NoError(t, <-written)
+
+	_, _, err := db.Compact()
+	require.NoError(t, err)
+	assert.Equal(t, "during", getValue(t, db, "b", "k"))
+}
+
+func TestReplaceRestoresDatabase(t *testing.T) {
+	db := newTestDB(t)
+	putValue(t, db, "b", "k", "kept")
+
+	broken := db.Path() + ".compact"
+	require.NoError(t, os.WriteFile(broken, []byte("not a database"), 0o666))
+	assert.Error(t, db.replace(broken))
+	assert.NoFileExists(t, db.Path()+".old")
+
+	// the original file is reopened
+	assert.Equal(t, "kept", getValue(t, db, "b", "k"))
+	putValue(t, db, "b", "k2", "written")
+	assert.Equal(t, "written", getValue(t, db, "b", "k2"))
+}
+
+func TestCompactFile(t *testing.T) {
+	db := newTestDB(t)
+	putValue(t, db, "b", "k", "kept")
+	path := db.Path()
+	require.NoError(t, db.Close())
+
+	dst := filepath.Join(t.TempDir(), "compacted.bdb")
+	require.NoError(t, CompactFile(path, dst))
+
+	db, err := NewBoltDB(dst)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, "kept", getValue(t, db, "b", "k"))
+}
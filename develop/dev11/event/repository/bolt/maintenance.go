@@ -0,0 +1,434 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"calendar/event"
+)
+
+// User bucket keys, everything else in a user bucket is unexpected
+var (
+	eventsKey   = []byte("events")
+	trashKey    = []byte("trash")
+	historyKey  = []byte("history")
+	tagsKey     = []byte("tags")
+	settingsKey = []byte("settings")
+)
+
+// UserInfo describes user bucket, OrgID is zero for buckets without user record
+type UserInfo struct {
+	ID     uint64 `json:"id"`
+	OrgID  uint64 `json:"org_id"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	Events int    `json:"events"`
+	Trash  int    `json:"trash"`
+}
+
+// Users lists users stored in the database sorted by id
+func Users(db *DB) ([]UserInfo, error) {
+	users := make([]UserInfo, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, user *bbolt.Bucket) error {
+			if len(name) != 8 {
+				return nil
+			}
+
+			info := UserInfo{ID: binary.BigEndian.Uint64(name)}
+			if u, err := getUser(tx, info.ID); err == nil {
+				info.OrgID, info.Name, info.Active = u.OrgID, u.Name, u.Active
+			}
+			if eBkt := user.Bucket(eventsKey); eBkt != nil {
+				info.Events = eBkt.Stats().KeyN
+			}
+			if tBkt := user.Bucket(trashKey); tBkt != nil {
+				info.Trash = tBkt.Stats().KeyN
+			}
+			users = append(users, info)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UserEvents returns all events of the user sorted by id
+func UserEvents(db *DB, user_id uint64) ([]event.Event, error) {
+	events := make([]event.Event, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		user := tx.Bucket(itob(user_id))
+		if user == nil {
+			return fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+		}
+
+		eBkt := user.Bucket(eventsKey)
+		if eBkt == nil {
+			return nil
+		}
+
+		return eBkt.ForEach(func(k, v []byte) error {
+			var e event.Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("user %d event %d: %w", user_id, binary.BigEndian.Uint64(k), err)
+			}
+			events = append(events, e)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MigrateUsers creates organization for user buckets created before users were introduced
+// and adds active user records for them. User adminID becomes organization admin,
+// the user with the smallest id is used when it is zero. It returns number of migrated users.
+func MigrateUsers(db *DB, orgName string, adminID uint64) (int, error) {
+	var n int
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var ids []uint64
+		err := tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+			if len(name) != 8 {
+				return nil
+			}
+			id := binary.BigEndian.Uint64(name)
+			if _, err := getUser(tx, id); err == nil {
+				return nil
+			}
+			ids = append(ids, id)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if adminID == 0 {
+			adminID = ids[0]
+		}
+		found := false
+		for _, id := range ids {
+			found = found || id == adminID
+		}
+		if !found {
+			return fmt.Errorf("%w: user %d is not among users to migrate", event.ErrNotFound, adminID)
+		}
+
+		oBkt, err := tx.CreateBucketIfNotExists(orgsKey)
+		if err != nil {
+			return err
+		}
+		org := event.Organization{Name: orgName, CreatedAt: time.Now()}
+		if org.ID, err = oBkt.NextSequence(); err != nil {
+			return err
+		}
+		if err := putJSON(oBkt, org.ID, org); err != nil {
+			return err
+		}
+
+		uBkt, err := tx.CreateBucketIfNotExists(usersKey)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			u := event.User{
+				ID:        id,
+				OrgID:     org.ID,
+				Name:      fmt.Sprintf("user %d", id),
+				Admin:     id == adminID,
+				Active:    true,
+				CreatedAt: org.CreatedAt,
+			}
+			if err := putJSON(uBkt, id, u); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Reindex rebuilds tag index of every user from stored events,
+// it returns number of indexed events. Malformed events are skipped, Verify reports them.
+func Reindex(db *DB) (int, error) {
+	var n int
+	err := db.Update(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, user *bbolt.Bucket) error {
+			if len(name) != 8 {
+				return nil
+			}
+
+			if user.Bucket(tagsKey) != nil {
+				if err := user.DeleteBucket(tagsKey); err != nil {
+					return err
+				}
+			}
+
+			eBkt := user.Bucket(eventsKey)
+			if eBkt == nil {
+				return nil
+			}
+			return eBkt.ForEach(func(k, v []byte) error {
+				var e event.Event
+				if v == nil || len(k) != 8 || json.Unmarshal(v, &e) != nil {
+					return nil
+				}
+				e.ID = binary.BigEndian.Uint64(k)
+				n++
+				return indexTags(user, e)
+			})
+		})
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Problem is an inconsistency found by Verify, Path is a slash-separated bucket path
+type Problem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// Verify checks that stored values are valid JSON, keys match event ids,
+// history and tag index don't refer to missing events and there are no unknown buckets.
+// It doesn't change the database, tag index problems are fixed by Reindex.
+func Verify(db *DB) ([]Problem, error) {
+	problems := make([]Problem, 0)
+	report := func(path, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	err := db.View(func(tx *bbolt.Tx) error {
+		verifyRecords(tx, report)
+
+		return tx.ForEach(func(name []byte, user *bbolt.Bucket) error {
+			if bytes.Equal(name, orgsKey) || bytes.Equal(name, usersKey) {
+				return nil
+			}
+			if len(name) != 8 {
+				report(fmt.Sprintf("%q", name), "unexpected root bucket")
+				return nil
+			}
+
+			path := fmt.Sprintf("%d", binary.BigEndian.Uint64(name))
+			if _, err := getUser(tx, binary.BigEndian.Uint64(name)); err != nil {
+				report(path, "no user record, run calendarctl migrate-users")
+			}
+			verifyUser(path, user, report)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// verifyRecords checks organization and user records
+func verifyRecords(tx *bbolt.Tx, report func(path, format string, args ...interface{})) {
+	orgs := make(map[uint64]struct{})
+	if oBkt := tx.Bucket(orgsKey); oBkt != nil {
+		oBkt.ForEach(func(k, v []byte) error {
+			p := fmt.Sprintf("orgs/%x", k)
+			if v == nil || len(k) != 8 {
+				report(p, "unexpected key")
+				return nil
+			}
+			id := binary.BigEndian.Uint64(k)
+
+			var o event.Organization
+			if err := json.Unmarshal(v, &o); err != nil {
+				report(fmt.Sprintf("orgs/%d", id), "malformed organization: %v", err)
+			}
+			orgs[id] = struct{}{}
+			return nil
+		})
+	}
+
+	if uBkt := tx.Bucket(usersKey); uBkt != nil {
+		uBkt.ForEach(func(k, v []byte) error {
+			p := fmt.Sprintf("users/%x", k)
+			if v == nil || len(k) != 8 {
+				report(p, "unexpected key")
+				return nil
+			}
+			p = fmt.Sprintf("users/%d", binary.BigEndian.Uint64(k))
+
+			var u event.User
+			if err := json.Unmarshal(v, &u); err != nil {
+				report(p, "malformed user: %v", err)
+				return nil
+			}
+			if _, ok := orgs[u.OrgID]; !ok {
+				report(p, "organization %d does not exist", u.OrgID)
+			}
+			if tx.Bucket(k) == nil {
+				report(p, "user has no events bucket")
+			}
+			return nil
+		})
+	}
+}
+
+func verifyUser(path string, user *bbolt.Bucket, report func(path, format string, args ...interface{})) {
+	events := make(map[uint64][]string)
+	trashed := make(map[uint64]struct{})
+
+	user.ForEach(func(k, v []byte) error {
+		switch {
+		case bytes.Equal(k, settingsKey) && v != nil:
+			var s event.UserSettings
+			if err := json.Unmarshal(v, &s); err != nil {
+				report(path+"/settings", "malformed settings: %v", err)
+			}
+		case v == nil && (bytes.Equal(k, eventsKey) || bytes.Equal(k, trashKey) || bytes.Equal(k, historyKey) || bytes.Equal(k, tagsKey)):
+		default:
+			report(fmt.Sprintf("%s/%q", path, k), "unexpected key")
+		}
+		return nil
+	})
+
+	if eBkt := user.Bucket(eventsKey); eBkt != nil {
+		eBkt.ForEach(func(k, v []byte) error {
+			p := fmt.Sprintf("%s/events/%x", path, k)
+			if v == nil || len(k) != 8 {
+				report(p, "unexpected key")
+				return nil
+			}
+			id := binary.BigEndian.Uint64(k)
+			p = fmt.Sprintf("%s/events/%d", path, id)
+
+			var e event.Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				report(p, "malformed event: %v", err)
+				events[id] = nil
+				return nil
+			}
+			if e.ID != id {
+				report(p, "event id %d doesn't match key", e.ID)
+			}
+			events[id] = e.Tags
+			return nil
+		})
+	}
+
+	if tBkt := user.Bucket(trashKey); tBkt != nil {
+		tBkt.ForEach(func(k, v []byte) error {
+			p := fmt.Sprintf("%s/trash/%x", path, k)
+			if v == nil || len(k) != 8 {
+				report(p, "unexpected key")
+				return nil
+			}
+			id := binary.BigEndian.Uint64(k)
+			p = fmt.Sprintf("%s/trash/%d", path, id)
+
+			var te event.TrashedEvent
+			if err := json.Unmarshal(v, &te); err != nil {
+				report(p, "malformed deleted event: %v", err)
+			}
+			if _, ok := events[id]; ok {
+				report(p, "event is both stored and deleted")
+			}
+			trashed[id] = struct{}{}
+			return nil
+		})
+	}
+
+	if hBkt := user.Bucket(historyKey); hBkt != nil {
+		hBkt.ForEach(func(k, v []byte) error {
+			p := fmt.Sprintf("%s/history/%x", path, k)
+			evBkt := hBkt.Bucket(k)
+			if evBkt == nil || len(k) != 8 {
+				report(p, "unexpected key")
+				return nil
+			}
+			id := binary.BigEndian.Uint64(k)
+			p = fmt.Sprintf("%s/history/%d", path, id)
+
+			_, stored := events[id]
+			_, deleted := trashed[id]
+			if !stored && !deleted {
+				report(p, "orphaned history of missing event")
+			}
+			evBkt.ForEach(func(k, v []byte) error {
+				var ver event.EventVersion
+				if err := json.Unmarshal(v, &ver); err != nil {
+					report(fmt.Sprintf("%s/%x", p, k), "malformed version: %v", err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+
+	indexed := make(map[string]map[uint64]struct{})
+	if tagsBkt := user.Bucket(tagsKey); tagsBkt != nil {
+		tagsBkt.ForEach(func(tag, v []byte) error {
+			p := fmt.Sprintf("%s/tags/%s", path, tag)
+			tagBkt := tagsBkt.Bucket(tag)
+			if tagBkt == nil {
+				report(p, "unexpected key")
+				return nil
+			}
+
+			ids := make(map[uint64]struct{})
+			tagBkt.ForEach(func(k, v []byte) error {
+				if len(k) != 8 {
+					report(fmt.Sprintf("%s/%x", p, k), "unexpected key")
+					return nil
+				}
+				id := binary.BigEndian.Uint64(k)
+				if _, ok := events[id]; !ok {
+					report(fmt.Sprintf("%s/%d", p, id), "orphaned index entry of missing event")
+				}
+				ids[id] = struct{}{}
+				return nil
+			})
+			if len(ids) == 0 {
+				report(p, "empty tag")
+			}
+			indexed[string(tag)] = ids
+			return nil
+		})
+	}
+
+	ids := make([]uint64, 0, len(events))
+	for id := range events {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		for _, tag := range events[id] {
+			if _, ok := indexed[tag][id]; !ok {
+				report(fmt.Sprintf("%s/events/%d", path, id), "tag %q is not indexed", tag)
+			}
+		}
+	}
+}
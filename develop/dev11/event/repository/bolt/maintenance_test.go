@@ -0,0 +1,237 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	"calendar/event"
+)
+
+var testDate = time.Date(2022, 7, 5, 10, 0, 0, 0, time.UTC)
+
+// updateUser runs fn on the bucket of user in a read-write transaction
+func updateUser(t *testing.T, db *DB, user_id uint64, fn func(user *bbolt.Bucket) error) {
+	t.Helper()
+
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		return fn(tx.Bucket(itob(user_id)))
+	}))
+}
+
+// createLegacyUser creates user bucket without user record, like ones created before organizations
+func createLegacyUser(t *testing.T, db *DB, user_id uint64, events ...event.Event) {
+	t.Helper()
+
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		user, err := tx.CreateBucket(itob(user_id))
+		if err != nil {
+			return err
+		}
+		eBkt, err := user.CreateBucket(eventsKey)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if e.ID, err = eBkt.NextSequence(); err != nil {
+				return err
+			}
+			if err := putJSON(eBkt, e.ID, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func TestUsers(t *testing.T) {
+	db := newTestDB(t)
+	createLegacyUser(t, db, 1, event.Event{Title: "legacy", Date: testDate})
+	alice := newTestUser(t, db)
+	store := NewBoltEventRepository(db)
+	for _, title := range []string{"sync", "review", "release"} {
+		_, err := store.Create(alice.ID, event.Event{Title: title, Date: testDate})
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Delete(alice.ID, 2))
+
+	users, err := Users(db)
+	require.NoError(t, err)
+	assert.Equal(t, []UserInfo{
+		{ID: 1, Events: 1},
+		{ID: alice.ID, OrgID: alice.OrgID, Name: "alice", Active: true, Events: 2, Trash: 1},
+	}, users)
+}
+
+func TestUserEvents(t *testing.T) {
+	db := newTestDB(t)
+	alice := newTestUser(t, db)
+	store := NewBoltEventRepository(db)
+	for _, title := range []string{"sync", "review"} {
+		_, err := store.Create(alice.ID, event.Event{Title: title, Date: testDate, Tags: []string{"meeting"}})
+		require.NoError(t, err)
+	}
+
+	events, err := UserEvents(db, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sync", "review"}, eventTitles(events))
+	assert.Equal(t, uint64(2), events[1].ID)
+
+	_, err = UserEvents(db, alice.ID+1)
+	assert.ErrorIs(t, err, event.ErrNotFound)
+
+	updateUser(t, db, alice.ID, func(user *bbolt.Bucket) error {
+		return user.Bucket(eventsKey).Put(itob(3), []byte("{"))
+	})
+	_, err = UserEvents(db, alice.ID)
+	assert.ErrorContains(t, err, "event 3")
+}
+
+func TestReindex(t *testing.T) {
+	db := newTestDB(t)
+	alice := newTestUser(t, db)
+	store := NewBoltEventRepository(db)
+	sync, err := store.Create(alice.ID, event.Event{Title: "sync", Date: testDate, Tags: []string{"meeting"}})
+	require.NoError(t, err)
+	_, err = store.Create(alice.ID, event.Event{Title: "release", Date: testDate, Tags: []string{"meeting", "release"}})
+	require.NoError(t, err)
+	createLegacyUser(t, db, 42, event.Event{Title: "legacy", Date: testDate, Tags: []string{"old"}})
+
+	// index lost entries of events and refers to missing ones
+	updateUser(t, db, alice.ID, func(user *bbolt.Bucket) error {
+		if err := user.DeleteBucket(tagsKey); err != nil {
+			return err
+		}
+		return indexTags(user, event.Event{ID: 7, Tags: []string{"meeting", "stale"}})
+	})
+
+	n, err := Reindex(db)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	problems, err := Verify(db)
+	require.NoError(t, err)
+	assert.Equal(t, []Problem{{Path: "42", Message: "no user record, run calendarctl migrate-users"}}, problems)
+
+	events, err := store.GetForRange(alice.ID, testDate, testDate.Add(time.Hour), event.TagFilter{Tags: []string{"meeting"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sync", "release"}, eventTitles(events))
+
+	tags, err := store.GetTags(alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []event.TagCount{{Tag: "meeting", Count: 2}, {Tag: "release", Count: 1}}, tags)
+
+	// deleted events are not indexed
+	require.NoError(t, store.Delete(alice.ID, sync.ID))
+	n, err = Reindex(db)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	tags, err = store.GetTags(alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []event.TagCount{{Tag: "meeting", Count: 1}, {Tag: "release", Count: 1}}, tags)
+}
+
+func TestVerify(t *testing.T) {
+	db := newTestDB(t)
+	alice := newTestUser(t, db)
+	store := NewBoltEventRepository(db)
+	sync, err := store.Create(alice.ID, event.Event{Title: "sync", Date: testDate, Tags: []string{"meeting"}})
+	require.NoError(t, err)
+	release, err := store.Create(alice.ID, event.Event{Title: "release", Date: testDate})
+	require.NoError(t, err)
+	require.NoError(t, store.Update(alice.ID, event.Event{ID: release.ID, Title: "release v2", Date: testDate}))
+	require.NoError(t, store.Delete(alice.ID, release.ID))
+
+	problems, err := Verify(db)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	updateUser(t, db, alice.ID, func(user *bbolt.Bucket) error {
+		if err := user.Bucket(eventsKey).Put(itob(10), []byte(`{"title":`)); err != nil {
+			return err
+		}
+		if err := user.Bucket(eventsKey).Put(itob(11), []byte(`{"id":12}`)); err != nil {
+			return err
+		}
+		hBkt, err := user.Bucket(historyKey).CreateBucket(itob(20))
+		if err != nil {
+			return err
+		}
+		if err := hBkt.Put(itob(1), []byte(`{}`)); err != nil {
+			return err
+		}
+		if err := indexTags(user, event.Event{ID: 30, Tags: []string{"meeting"}}); err != nil {
+			return err
+		}
+		if err := user.Bucket(tagsKey).Bucket([]byte("meeting")).Delete(itob(sync.ID)); err != nil {
+			return err
+		}
+		return user.Put([]byte("junk"), []byte("x"))
+	})
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucket([]byte("junk"))
+		return err
+	}))
+
+	problems, err = Verify(db)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Problem{
+		{Path: `"junk"`, Message: "unexpected root bucket"},
+		{Path: `1/"junk"`, Message: "unexpected key"},
+		{Path: "1/events/10", Message: "malformed event: unexpected end of JSON input"},
+		{Path: "1/events/11", Message: "event id 12 doesn't match key"},
+		{Path: "1/history/20", Message: "orphaned history of missing event"},
+		{Path: "1/tags/meeting/30", Message: "orphaned index entry of missing event"},
+		{Path: "1/events/1", Message: `tag "meeting" is not indexed`},
+	}, problems)
+}
+
+func TestVerifyRecords(t *testing.T) {
+	db := newTestDB(t)
+	newTestUser(t, db)
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		uBkt := tx.Bucket(usersKey)
+		if err := putJSON(uBkt, 5, event.User{ID: 5, OrgID: 9}); err != nil {
+			return err
+		}
+		if err := uBkt.Put(itob(6), []byte("{")); err != nil {
+			return err
+		}
+		return tx.Bucket(orgsKey).Put(itob(2), []byte("["))
+	}))
+
+	problems, err := Verify(db)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Problem{
+		{Path: "orgs/2", Message: "malformed organization: unexpected end of JSON input"},
+		{Path: "users/5", Message: "organization 9 does not exist"},
+		{Path: "users/5", Message: "user has no events bucket"},
+		{Path: "users/6", Message: "malformed user: unexpected end of JSON input"},
+	}, problems)
+}
+
+func TestReadOnlyLockedDatabase(t *testing.T) {
+	db := newTestDB(t)
+	alice := newTestUser(t, db)
+
+	// running server holds exclusive lock
+	_, err := NewReadOnlyBoltDB(db.Path(), 50*time.Millisecond)
+	assert.ErrorIs(t, err, bbolt.ErrTimeout)
+
+	path := db.Path()
+	require.NoError(t, db.Close())
+	ro, err := NewReadOnlyBoltDB(path, 50*time.Millisecond)
+	require.NoError(t, err)
+	defer ro.Close()
+
+	users, err := Users(ro)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, alice.ID, users[0].ID)
+
+	_, err = Reindex(ro)
+	assert.ErrorIs(t, err, bbolt.ErrDatabaseReadOnly)
+}
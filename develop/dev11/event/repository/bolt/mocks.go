@@ -31,12 +31,39 @@ var _ event.EventRepository = &EventRepositoryMock{}
 // 			GetForMonthFunc: func(user_id uint64, month time.Time) ([]event.Event, error) {
 // 				panic("mock out the GetForMonth method")
 // 			},
+// 			GetForRangeFunc: func(user_id uint64, from time.Time, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+// 				panic("mock out the GetForRange method")
+// 			},
 // 			GetForWeekFunc: func(user_id uint64, week time.Time) ([]event.Event, error) {
 // 				panic("mock out the GetForWeek method")
 // 			},
+// 			GetHistoryFunc: func(user_id uint64, event_id uint64) ([]event.EventVersion, error) {
+// 				panic("mock out the GetHistory method")
+// 			},
+// 			GetOverlappingFunc: func(user_id uint64, from time.Time, to time.Time) ([]event.Event, error) {
+// 				panic("mock out the GetOverlapping method")
+// 			},
+// 			GetSettingsFunc: func(user_id uint64) (event.UserSettings, error) {
+// 				panic("mock out the GetSettings method")
+// 			},
+// 			GetTagsFunc: func(user_id uint64) ([]event.TagCount, error) {
+// 				panic("mock out the GetTags method")
+// 			},
+// 			GetTrashFunc: func(user_id uint64) ([]event.TrashedEvent, error) {
+// 				panic("mock out the GetTrash method")
+// 			},
+// 			PurgeTrashFunc: func(before time.Time) (int, error) {
+// 				panic("mock out the PurgeTrash method")
+// 			},
+// 			RestoreFunc: func(user_id uint64, event_id uint64) (event.Event, error) {
+// 				panic("mock out the Restore method")
+// 			},
 // 			UpdateFunc: func(user_id uint64, e event.Event) error {
 // 				panic("mock out the Update method")
 // 			},
+// 			UpdateSettingsFunc: func(user_id uint64, s event.UserSettings) error {
+// 				panic("mock out the UpdateSettings method")
+// 			},
 // 		}
 //
 // 		// use mockedEventRepository in code that requires event.EventRepository
@@ -56,12 +83,39 @@ type EventRepositoryMock struct {
 	// GetForMonthFunc mocks the GetForMonth method.
 	GetForMonthFunc func(user_id uint64, month time.Time) ([]event.Event, error)
 
+	// GetForRangeFunc mocks the GetForRange method.
+	GetForRangeFunc func(user_id uint64, from time.Time, to time.Time, filter event.TagFilter) ([]event.Event, error)
+
 	// GetForWeekFunc mocks the GetForWeek method.
 	GetForWeekFunc func(user_id uint64, week time.Time) ([]event.Event, error)
 
+	// GetHistoryFunc mocks the GetHistory method.
+	GetHistoryFunc func(user_id uint64, event_id uint64) ([]event.EventVersion, error)
+
+	// GetOverlappingFunc mocks the GetOverlapping method.
+	GetOverlappingFunc func(user_id uint64, from time.Time, to time.Time) ([]event.Event, error)
+
+	// GetSettingsFunc mocks the GetSettings method.
+	GetSettingsFunc func(user_id uint64) (event.UserSettings, error)
+
+	// GetTagsFunc mocks the GetTags method.
+	GetTagsFunc func(user_id uint64) ([]event.TagCount, error)
+
+	// GetTrashFunc mocks the GetTrash method.
+	GetTrashFunc func(user_id uint64) ([]event.TrashedEvent, error)
+
+	// PurgeTrashFunc mocks the PurgeTrash method.
+	PurgeTrashFunc func(before time.Time) (int, error)
+
+	// RestoreFunc mocks the Restore method.
+	RestoreFunc func(user_id uint64, event_id uint64) (event.Event, error)
+
 	// UpdateFunc mocks the Update method.
 	UpdateFunc func(user_id uint64, e event.Event) error
 
+	// UpdateSettingsFunc mocks the UpdateSettings method.
+	UpdateSettingsFunc func(user_id uint64, s event.UserSettings) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// Create holds details about calls to the Create method.
@@ -92,6 +146,17 @@ type EventRepositoryMock struct {
 			// Month is the month argument value.
 			Month time.Time
 		}
+		// GetForRange holds details about calls to the GetForRange method.
+		GetForRange []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+			// From is the from argument value.
+			From time.Time
+			// To is the to argument value.
+			To time.Time
+			// Filter is the filter argument value.
+			Filter event.TagFilter
+		}
 		// GetForWeek holds details about calls to the GetForWeek method.
 		GetForWeek []struct {
 			// User_id is the user_id argument value.
@@ -99,6 +164,49 @@ type EventRepositoryMock struct {
 			// Week is the week argument value.
 			Week time.Time
 		}
+		// GetHistory holds details about calls to the GetHistory method.
+		GetHistory []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+			// Event_id is the event_id argument value.
+			Event_id uint64
+		}
+		// GetOverlapping holds details about calls to the GetOverlapping method.
+		GetOverlapping []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+			// From is the from argument value.
+			From time.Time
+			// To is the to argument value.
+			To time.Time
+		}
+		// GetSettings holds details about calls to the GetSettings method.
+		GetSettings []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+		}
+		// GetTags holds details about calls to the GetTags method.
+		GetTags []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+		}
+		// GetTrash holds details about calls to the GetTrash method.
+		GetTrash []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+		}
+		// PurgeTrash holds details about calls to the PurgeTrash method.
+		PurgeTrash []struct {
+			// Before is the before argument value.
+			Before time.Time
+		}
+		// Restore holds details about calls to the Restore method.
+		Restore []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+			// Event_id is the event_id argument value.
+			Event_id uint64
+		}
 		// Update holds details about calls to the Update method.
 		Update []struct {
 			// User_id is the user_id argument value.
@@ -106,13 +214,29 @@ type EventRepositoryMock struct {
 			// E is the e argument value.
 			E event.Event
 		}
+		// UpdateSettings holds details about calls to the UpdateSettings method.
+		UpdateSettings []struct {
+			// User_id is the user_id argument value.
+			User_id uint64
+			// S is the s argument value.
+			S event.UserSettings
+		}
 	}
-	lockCreate      sync.RWMutex
-	lockDelete      sync.RWMutex
-	lockGetForDay   sync.RWMutex
-	lockGetForMonth sync.RWMutex
-	lockGetForWeek  sync.RWMutex
-	lockUpdate      sync.RWMutex
+	lockCreate         sync.RWMutex
+	lockDelete         sync.RWMutex
+	lockGetForDay      sync.RWMutex
+	lockGetForMonth    sync.RWMutex
+	lockGetForRange    sync.RWMutex
+	lockGetForWeek     sync.RWMutex
+	lockGetHistory     sync.RWMutex
+	lockGetOverlapping sync.RWMutex
+	lockGetSettings    sync.RWMutex
+	lockGetTags        sync.RWMutex
+	lockGetTrash       sync.RWMutex
+	lockPurgeTrash     sync.RWMutex
+	lockRestore        sync.RWMutex
+	lockUpdate         sync.RWMutex
+	lockUpdateSettings sync.RWMutex
 }
 
 // Create calls CreateFunc.
@@ -255,6 +379,49 @@ func (mock *EventRepositoryMock) GetForMonthCalls() []struct {
 	return calls
 }
 
+// GetForRange calls GetForRangeFunc.
+func (mock *EventRepositoryMock) GetForRange(user_id uint64, from time.Time, to time.Time, filter event.TagFilter) ([]event.Event, error) {
+	if mock.GetForRangeFunc == nil {
+		panic("EventRepositoryMock.GetForRangeFunc: method is nil but EventRepository.GetForRange was just called")
+	}
+	callInfo := struct {
+		User_id uint64
+		From    time.Time
+		To      time.Time
+		Filter  event.TagFilter
+	}{
+		User_id: user_id,
+		From:    from,
+		To:      to,
+		Filter:  filter,
+	}
+	mock.lockGetForRange.Lock()
+	mock.calls.GetForRange = append(mock.calls.GetForRange, callInfo)
+	mock.lockGetForRange.Unlock()
+	return mock.GetForRangeFunc(user_id, from, to, filter)
+}
+
+// GetForRangeCalls gets all the calls that were made to GetForRange.
+// Check the length with:
+//     len(mockedEventRepository.GetForRangeCalls())
+func (mock *EventRepositoryMock) GetForRangeCalls() []struct {
+	User_id uint64
+	From    time.Time
+	To      time.Time
+	Filter  event.TagFilter
+} {
+	var calls []struct {
+		User_id uint64
+		From    time.Time
+		To      time.Time
+		Filter  event.TagFilter
+	}
+	mock.lockGetForRange.RLock()
+	calls = mock.calls.GetForRange
+	mock.lockGetForRange.RUnlock()
+	return calls
+}
+
 // GetForWeek calls GetForWeekFunc.
 func (mock *EventRepositoryMock) GetForWeek(user_id uint64, week time.Time) ([]event.Event, error) {
 	if mock.GetForWeekFunc == nil {
@@ -290,6 +457,239 @@ func (mock *EventRepositoryMock) GetForWeekCalls() []struct {
 	return calls
 }
 
+// GetHistory calls GetHistoryFunc.
+func (mock *EventRepositoryMock) GetHistory(user_id uint64, event_id uint64) ([]event.EventVersion, error) {
+	if mock.GetHistoryFunc == nil {
+		panic("EventRepositoryMock.GetHistoryFunc: method is nil but EventRepository.GetHistory was just called")
+	}
+	callInfo := struct {
+		User_id  uint64
+		Event_id uint64
+	}{
+		User_id:  user_id,
+		Event_id: event_id,
+	}
+	mock.lockGetHistory.Lock()
+	mock.calls.GetHistory = append(mock.calls.GetHistory, callInfo)
+	mock.lockGetHistory.Unlock()
+	return mock.GetHistoryFunc(user_id, event_id)
+}
+
+// GetHistoryCalls gets all the calls that were made to GetHistory.
+// Check the length with:
+//     len(mockedEventRepository.GetHistoryCalls())
+func (mock *EventRepositoryMock) GetHistoryCalls() []struct {
+	User_id  uint64
+	Event_id uint64
+} {
+	var calls []struct {
+		User_id  uint64
+		Event_id uint64
+	}
+	mock.lockGetHistory.RLock()
+	calls = mock.calls.GetHistory
+	mock.lockGetHistory.RUnlock()
+	return calls
+}
+
+// GetOverlapping calls GetOverlappingFunc.
+func (mock *EventRepositoryMock) GetOverlapping(user_id uint64, from time.Time, to time.Time) ([]event.Event, error) {
+	if mock.GetOverlappingFunc == nil {
+		panic("EventRepositoryMock.GetOverlappingFunc: method is nil but EventRepository.GetOverlapping was just called")
+	}
+	callInfo := struct {
+		User_id uint64
+		From    time.Time
+		To      time.Time
+	}{
+		User_id: user_id,
+		From:    from,
+		To:      to,
+	}
+	mock.lockGetOverlapping.Lock()
+	mock.calls.GetOverlapping = append(mock.calls.GetOverlapping, callInfo)
+	mock.lockGetOverlapping.Unlock()
+	return mock.GetOverlappingFunc(user_id, from, to)
+}
+
+// GetOverlappingCalls gets all the calls that were made to GetOverlapping.
+// Check the length with:
+//     len(mockedEventRepository.GetOverlappingCalls())
+func (mock *EventRepositoryMock) GetOverlappingCalls() []struct {
+	User_id uint64
+	From    time.Time
+	To      time.Time
+} {
+	var calls []struct {
+		User_id uint64
+		From    time.Time
+		To      time.Time
+	}
+	mock.lockGetOverlapping.RLock()
+	calls = mock.calls.GetOverlapping
+	mock.lockGetOverlapping.RUnlock()
+	return calls
+}
+
+// GetSettings calls GetSettingsFunc.
+func (mock *EventRepositoryMock) GetSettings(user_id uint64) (event.UserSettings, error) {
+	if mock.GetSettingsFunc == nil {
+		panic("EventRepositoryMock.GetSettingsFunc: method is nil but EventRepository.GetSettings was just called")
+	}
+	callInfo := struct {
+		User_id uint64
+	}{
+		User_id: user_id,
+	}
+	mock.lockGetSettings.Lock()
+	mock.calls.GetSettings = append(mock.calls.GetSettings, callInfo)
+	mock.lockGetSettings.Unlock()
+	return mock.GetSettingsFunc(user_id)
+}
+
+// GetSettingsCalls gets all the calls that were made to GetSettings.
+// Check the length with:
+//     len(mockedEventRepository.GetSettingsCalls())
+func (mock *EventRepositoryMock) GetSettingsCalls() []struct {
+	User_id uint64
+} {
+	var calls []struct {
+		User_id uint64
+	}
+	mock.lockGetSettings.RLock()
+	calls = mock.calls.GetSettings
+	mock.lockGetSettings.RUnlock()
+	return calls
+}
+
+// GetTags calls GetTagsFunc.
+func (mock *EventRepositoryMock) GetTags(user_id uint64) ([]event.TagCount, error) {
+	if mock.GetTagsFunc == nil {
+		panic("EventRepositoryMock.GetTagsFunc: method is nil but EventRepository.GetTags was just called")
+	}
+	callInfo := struct {
+		User_id uint64
+	}{
+		User_id: user_id,
+	}
+	mock.lockGetTags.Lock()
+	mock.calls.GetTags = append(mock.calls.GetTags, callInfo)
+	mock.lockGetTags.Unlock()
+	return mock.GetTagsFunc(user_id)
+}
+
+// GetTagsCalls gets all the calls that were made to GetTags.
+// Check the length with:
+//     len(mockedEventRepository.GetTagsCalls())
+func (mock *EventRepositoryMock) GetTagsCalls() []struct {
+	User_id uint64
+} {
+	var calls []struct {
+		User_id uint64
+	}
+	mock.lockGetTags.RLock()
+	calls = mock.calls.GetTags
+	mock.lockGetTags.RUnlock()
+	return calls
+}
+
+// GetTrash calls GetTrashFunc.
+func (mock *EventRepositoryMock) GetTrash(user_id uint64) ([]event.TrashedEvent, error) {
+	if mock.GetTrashFunc == nil {
+		panic("EventRepositoryMock.GetTrashFunc: method is nil but EventRepository.GetTrash was just called")
+	}
+	callInfo := struct {
+		User_id uint64
+	}{
+		User_id: user_id,
+	}
+	mock.lockGetTrash.Lock()
+	mock.calls.GetTrash = append(mock.calls.GetTrash, callInfo)
+	mock.lockGetTrash.Unlock()
+	return mock.GetTrashFunc(user_id)
+}
+
+// GetTrashCalls gets all the calls that were made to GetTrash.
+// Check the length with:
+//     len(mockedEventRepository.GetTrashCalls())
+func (mock *EventRepositoryMock) GetTrashCalls() []struct {
+	User_id uint64
+} {
+	var calls []struct {
+		User_id uint64
+	}
+	mock.lockGetTrash.RLock()
+	calls = mock.calls.GetTrash
+	mock.lockGetTrash.RUnlock()
+	return calls
+}
+
+// PurgeTrash calls PurgeTrashFunc.
+func (mock *EventRepositoryMock) PurgeTrash(before time.Time) (int, error) {
+	if mock.PurgeTrashFunc == nil {
+		panic("EventRepositoryMock.PurgeTrashFunc: method is nil but EventRepository.PurgeTrash was just called")
+	}
+	callInfo := struct {
+		Before time.Time
+	}{
+		Before: before,
+	}
+	mock.lockPurgeTrash.Lock()
+	mock.calls.PurgeTrash = append(mock.calls.PurgeTrash, callInfo)
+	mock.lockPurgeTrash.Unlock()
+	return mock.PurgeTrashFunc(before)
+}
+
+// PurgeTrashCalls gets all the calls that were made to PurgeTrash.
+// Check the length with:
+//     len(mockedEventRepository.PurgeTrashCalls())
+func (mock *EventRepositoryMock) PurgeTrashCalls() []struct {
+	Before time.Time
+} {
+	var calls []struct {
+		Before time.Time
+	}
+	mock.lockPurgeTrash.RLock()
+	calls = mock.calls.PurgeTrash
+	mock.lockPurgeTrash.RUnlock()
+	return calls
+}
+
+// Restore calls RestoreFunc.
+func (mock *EventRepositoryMock) Restore(user_id uint64, event_id uint64) (event.Event, error) {
+	if mock.RestoreFunc == nil {
+		panic("EventRepositoryMock.RestoreFunc: method is nil but EventRepository.Restore was just called")
+	}
+	callInfo := struct {
+		User_id  uint64
+		Event_id uint64
+	}{
+		User_id:  user_id,
+		Event_id: event_id,
+	}
+	mock.lockRestore.Lock()
+	mock.calls.Restore = append(mock.calls.Restore, callInfo)
+	mock.lockRestore.Unlock()
+	return mock.RestoreFunc(user_id, event_id)
+}
+
+// RestoreCalls gets all the calls that were made to Restore.
+// Check the length with:
+//     len(mockedEventRepository.RestoreCalls())
+func (mock *EventRepositoryMock) RestoreCalls() []struct {
+	User_id  uint64
+	Event_id uint64
+} {
+	var calls []struct {
+		User_id  uint64
+		Event_id uint64
+	}
+	mock.lockRestore.RLock()
+	calls = mock.calls.Restore
+	mock.lockRestore.RUnlock()
+	return calls
+}
+
 // Update calls UpdateFunc.
 func (mock *EventRepositoryMock) Update(user_id uint64, e event.Event) error {
 	if mock.UpdateFunc == nil {
@@ -324,3 +724,38 @@ func (mock *EventRepositoryMock) UpdateCalls() []struct {
 	mock.lockUpdate.RUnlock()
 	return calls
 }
+
+// UpdateSettings calls UpdateSettingsFunc.
+func (mock *EventRepositoryMock) UpdateSettings(user_id uint64, s event.UserSettings) error {
+	if mock.UpdateSettingsFunc == nil {
+		panic("EventRepositoryMock.UpdateSettingsFunc: method is nil but EventRepository.UpdateSettings was just called")
+	}
+	callInfo := struct {
+		User_id uint64
+		S       event.UserSettings
+	}{
+		User_id: user_id,
+		S:       s,
+	}
+	mock.lockUpdateSettings.Lock()
+	mock.calls.UpdateSettings = append(mock.calls.UpdateSettings, callInfo)
+	mock.lockUpdateSettings.Unlock()
+	return mock.UpdateSettingsFunc(user_id, s)
+}
+
+// UpdateSettingsCalls gets all the calls that were made to UpdateSettings.
+// Check the length with:
+//     len(mockedEventRepository.UpdateSettingsCalls())
+func (mock *EventRepositoryMock) UpdateSettingsCalls() []struct {
+	User_id uint64
+	S       event.UserSettings
+} {
+	var calls []struct {
+		User_id uint64
+		S       event.UserSettings
+	}
+	mock.lockUpdateSettings.RLock()
+	calls = mock.calls.UpdateSettings
+	mock.lockUpdateSettings.RUnlock()
+	return calls
+}
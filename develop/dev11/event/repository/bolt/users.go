@@ -0,0 +1,349 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"calendar/event"
+)
+
+// Root buckets with organization and user records, user events are stored
+// in a separate root bucket named after user id
+var (
+	orgsKey  = []byte("orgs")
+	usersKey = []byte("users")
+)
+
+type boltUserRepository struct {
+	db *DB
+}
+
+func NewBoltUserRepository(db *DB) event.UserRepository {
+	return &boltUserRepository{
+		db: db,
+	}
+}
+
+// CreateOrg creates organization with its first admin
+func (b *boltUserRepository) CreateOrg(o event.Organization, admin event.User) (event.Organization, event.User, error) {
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		oBkt, err := tx.CreateBucketIfNotExists(orgsKey)
+		if err != nil {
+			return err
+		}
+		o.ID, err = oBkt.NextSequence()
+		if err != nil {
+			return err
+		}
+		o.CreatedAt = time.Now()
+		if err := putJSON(oBkt, o.ID, o); err != nil {
+			return err
+		}
+
+		admin.OrgID = o.ID
+		admin.Admin = true
+		admin.Active = true
+		admin, err = createUser(tx, admin)
+		return err
+	})
+
+	if err != nil {
+		return event.Organization{}, event.User{}, err
+	}
+	return o, admin, nil
+}
+
+func (b *boltUserRepository) UpdateOrg(o event.Organization) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		old, err := getOrg(tx, o.ID)
+		if err != nil {
+			return err
+		}
+		old.Name = o.Name
+
+		return putJSON(tx.Bucket(orgsKey), old.ID, old)
+	})
+}
+
+// DeleteOrg deletes organization with all its users and their events
+func (b *boltUserRepository) DeleteOrg(org_id uint64) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		if _, err := getOrg(tx, org_id); err != nil {
+			return err
+		}
+
+		users, err := listUsers(tx, org_id)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := deleteUser(tx, u.ID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(orgsKey).Delete(itob(org_id))
+	})
+}
+
+func (b *boltUserRepository) GetOrg(org_id uint64) (event.Organization, error) {
+	var result event.Organization
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		result, err = getOrg(tx, org_id)
+		return err
+	})
+
+	if err != nil {
+		return event.Organization{}, err
+	}
+	return result, nil
+}
+
+func (b *boltUserRepository) ListOrgs() ([]event.Organization, error) {
+	orgs := make([]event.Organization, 0)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		oBkt := tx.Bucket(orgsKey)
+		if oBkt == nil {
+			return nil
+		}
+
+		return oBkt.ForEach(func(k, v []byte) error {
+			var o event.Organization
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			orgs = append(orgs, o)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// CreateUser creates active user in existing organization
+func (b *boltUserRepository) CreateUser(u event.User) (event.User, error) {
+	var result event.User
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		if _, err := getOrg(tx, u.OrgID); err != nil {
+			return err
+		}
+
+		u.Active = true
+		var err error
+		result, err = createUser(tx, u)
+		return err
+	})
+
+	if err != nil {
+		return event.User{}, err
+	}
+	return result, nil
+}
+
+// UpdateUser changes user name, admin and active flags, organization can't be changed.
+// The last active admin of organization can't be demoted or deactivated.
+func (b *boltUserRepository) UpdateUser(u event.User) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		old, err := getUser(tx, u.ID)
+		if err != nil {
+			return err
+		}
+
+		if old.CanManage(old.OrgID) && !(u.Admin && u.Active) {
+			if err := checkNotLastAdmin(tx, old); err != nil {
+				return err
+			}
+		}
+
+		old.Name = u.Name
+		old.Admin = u.Admin
+		old.Active = u.Active
+		return putJSON(tx.Bucket(usersKey), old.ID, old)
+	})
+}
+
+// DeleteUser deletes user with all events, the last active admin
+// can be deleted only with the organization
+func (b *boltUserRepository) DeleteUser(user_id uint64) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		u, err := getUser(tx, user_id)
+		if err != nil {
+			return err
+		}
+
+		if u.CanManage(u.OrgID) {
+			if err := checkNotLastAdmin(tx, u); err != nil {
+				return err
+			}
+		}
+
+		return deleteUser(tx, user_id)
+	})
+}
+
+func (b *boltUserRepository) GetUser(user_id uint64) (event.User, error) {
+	var result event.User
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		result, err = getUser(tx, user_id)
+		return err
+	})
+
+	if err != nil {
+		return event.User{}, err
+	}
+	return result, nil
+}
+
+func (b *boltUserRepository) ListUsers(org_id uint64) ([]event.User, error) {
+	var result []event.User
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		if _, err := getOrg(tx, org_id); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = listUsers(tx, org_id)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// userBucket returns events bucket of active user
+func userBucket(tx *bbolt.Tx, user_id uint64) (*bbolt.Bucket, error) {
+	u, err := getUser(tx, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.Active {
+		return nil, fmt.Errorf("%w: user %d is deactivated", event.ErrForbidden, user_id)
+	}
+
+	user := tx.Bucket(itob(user_id))
+	if user == nil {
+		return nil, fmt.Errorf("user %d has no data bucket", user_id)
+	}
+	return user, nil
+}
+
+func getOrg(tx *bbolt.Tx, org_id uint64) (event.Organization, error) {
+	var o event.Organization
+	oBkt := tx.Bucket(orgsKey)
+	if oBkt == nil {
+		return o, fmt.Errorf("%w: organization %d does not exist", event.ErrNotFound, org_id)
+	}
+
+	v := oBkt.Get(itob(org_id))
+	if v == nil {
+		return o, fmt.Errorf("%w: organization %d does not exist", event.ErrNotFound, org_id)
+	}
+	err := json.Unmarshal(v, &o)
+	return o, err
+}
+
+func getUser(tx *bbolt.Tx, user_id uint64) (event.User, error) {
+	var u event.User
+	uBkt := tx.Bucket(usersKey)
+	if uBkt == nil {
+		return u, fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+	}
+
+	v := uBkt.Get(itob(user_id))
+	if v == nil {
+		return u, fmt.Errorf("%w: user %d does not exist", event.ErrNotFound, user_id)
+	}
+	err := json.Unmarshal(v, &u)
+	return u, err
+}
+
+func listUsers(tx *bbolt.Tx, org_id uint64) ([]event.User, error) {
+	users := make([]event.User, 0)
+	uBkt := tx.Bucket(usersKey)
+	if uBkt == nil {
+		return users, nil
+	}
+
+	err := uBkt.ForEach(func(k, v []byte) error {
+		var u event.User
+		if err := json.Unmarshal(v, &u); err != nil {
+			return err
+		}
+		if u.OrgID == org_id {
+			users = append(users, u)
+		}
+		return nil
+	})
+	return users, err
+}
+
+// createUser stores user record and creates bucket for user events,
+// ids of buckets created before users were introduced are skipped
+func createUser(tx *bbolt.Tx, u event.User) (event.User, error) {
+	uBkt, err := tx.CreateBucketIfNotExists(usersKey)
+	if err != nil {
+		return u, err
+	}
+
+	for {
+		u.ID, err = uBkt.NextSequence()
+		if err != nil {
+			return u, err
+		}
+		if tx.Bucket(itob(u.ID)) == nil {
+			break
+		}
+	}
+	u.CreatedAt = time.Now()
+	u.Events = nil
+
+	user, err := tx.CreateBucket(itob(u.ID))
+	if err != nil {
+		return u, err
+	}
+	if _, err := user.CreateBucket(eventsKey); err != nil {
+		return u, err
+	}
+	return u, putJSON(uBkt, u.ID, u)
+}
+
+func deleteUser(tx *bbolt.Tx, user_id uint64) error {
+	if tx.Bucket(itob(user_id)) != nil {
+		if err := tx.DeleteBucket(itob(user_id)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(usersKey).Delete(itob(user_id))
+}
+
+// checkNotLastAdmin fails when u is the only active admin of its organization
+func checkNotLastAdmin(tx *bbolt.Tx, u event.User) error {
+	users, err := listUsers(tx, u.OrgID)
+	if err != nil {
+		return err
+	}
+	for _, other := range users {
+		if other.ID != u.ID && other.CanManage(u.OrgID) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: user %d is the last admin of organization %d", event.ErrConflict, u.ID, u.OrgID)
+}
+
+func putJSON(bkt *bbolt.Bucket, id uint64, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bkt.Put(itob(id), buf)
+}
@@ -0,0 +1,234 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	"calendar/event"
+)
+
+func TestCreateUserSkipsLegacyBuckets(t *testing.T) {
+	db := newTestDB(t)
+	createLegacyUser(t, db, 1, event.Event{Title: "legacy", Date: testDate})
+	createLegacyUser(t, db, 2)
+	repo := NewBoltUserRepository(db)
+
+	org, admin, err := repo.CreateOrg(event.Organization{Name: "acme"}, event.User{Name: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), admin.ID)
+	assert.True(t, admin.Admin)
+	assert.True(t, admin.Active)
+
+	bob, err := repo.CreateUser(event.User{OrgID: org.ID, Name: "bob", Admin: true})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), bob.ID)
+	assert.True(t, bob.Active)
+
+	// legacy events are kept
+	events, err := UserEvents(db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, eventTitles(events))
+
+	_, err = repo.CreateUser(event.User{OrgID: org.ID + 1, Name: "eve"})
+	assert.ErrorIs(t, err, event.ErrNotFound)
+}
+
+func TestLastAdmin(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewBoltUserRepository(db)
+	org, alice, err := repo.CreateOrg(event.Organization{Name: "acme"}, event.User{Name: "alice"})
+	require.NoError(t, err)
+	bob, err := repo.CreateUser(event.User{OrgID: org.ID, Name: "bob"})
+	require.NoError(t, err)
+	_, other, err := repo.CreateOrg(event.Organization{Name: "other"}, event.User{Name: "carol"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc   string
+		update event.User
+	}{
+		{desc: "demote", update: event.User{ID: alice.ID, Name: "alice", Active: true}},
+		{desc: "deactivate", update: event.User{ID: alice.ID, Name: "alice", Admin: true}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.ErrorIs(t, repo.UpdateUser(tC.update), event.ErrConflict)
+		})
+	}
+	assert.ErrorIs(t, repo.DeleteUser(alice.ID), event.ErrConflict)
+
+	// admin of another organization doesn't count
+	require.NoError(t, repo.UpdateUser(event.User{ID: other.ID, Name: "carol", Admin: true, Active: true}))
+	assert.ErrorIs(t, repo.DeleteUser(alice.ID), event.ErrConflict)
+
+	// deactivated admin doesn't count
+	require.NoError(t, repo.UpdateUser(event.User{ID: bob.ID, Name: "bob", Admin: true}))
+	assert.ErrorIs(t, repo.DeleteUser(alice.ID), event.ErrConflict)
+
+	require.NoError(t, repo.UpdateUser(event.User{ID: bob.ID, Name: "bob", Admin: true, Active: true}))
+	require.NoError(t, repo.UpdateUser(event.User{ID: alice.ID, Name: "alice", Active: true}))
+	assert.ErrorIs(t, repo.UpdateUser(event.User{ID: bob.ID, Name: "bob"}), event.ErrConflict)
+	require.NoError(t, repo.DeleteUser(alice.ID))
+
+	_, err = repo.GetUser(alice.ID)
+	assert.ErrorIs(t, err, event.ErrNotFound)
+	users, err := repo.ListUsers(org.ID)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "bob", users[0].Name)
+}
+
+func TestDeactivatedUser(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewBoltUserRepository(db)
+	store := NewBoltEventRepository(db)
+	org, _, err := repo.CreateOrg(event.Organization{Name: "acme"}, event.User{Name: "alice"})
+	require.NoError(t, err)
+	bob, err := repo.CreateUser(event.User{OrgID: org.ID, Name: "bob"})
+	require.NoError(t, err)
+	e, err := store.Create(bob.ID, event.Event{Title: "sync", Date: testDate})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.UpdateUser(event.User{ID: bob.ID, Name: "robert"}))
+	u, err := repo.GetUser(bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "robert", u.Name)
+	assert.Equal(t, org.ID, u.OrgID)
+	assert.False(t, u.Active)
+
+	_, err = store.Create(bob.ID, event.Event{Title: "review", Date: testDate})
+	assert.ErrorIs(t, err, event.ErrForbidden)
+	_, err = store.GetForDay(bob.ID, testDate)
+	assert.ErrorIs(t, err, event.ErrForbidden)
+	assert.ErrorIs(t, store.Delete(bob.ID, e.ID), event.ErrForbidden)
+
+	// events are kept while user is deactivated
+	require.NoError(t, repo.UpdateUser(event.User{ID: bob.ID, Name: "robert", Active: true}))
+	events, err := store.GetForDay(bob.ID, testDate)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sync"}, eventTitles(events))
+}
+
+func TestDeleteOrg(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewBoltUserRepository(db)
+	store := NewBoltEventRepository(db)
+	org, alice, err := repo.CreateOrg(event.Organization{Name: "acme"}, event.User{Name: "alice"})
+	require.NoError(t, err)
+	bob, err := repo.CreateUser(event.User{OrgID: org.ID, Name: "bob"})
+	require.NoError(t, err)
+	other, carol, err := repo.CreateOrg(event.Organization{Name: "other"}, event.User{Name: "carol"})
+	require.NoError(t, err)
+	for _, u := range []event.User{alice, bob, carol} {
+		_, err := store.Create(u.ID, event.Event{Title: "sync", Date: testDate})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, repo.DeleteOrg(org.ID))
+
+	_, err = repo.GetOrg(org.ID)
+	assert.ErrorIs(t, err, event.ErrNotFound)
+	_, err = repo.ListUsers(org.ID)
+	assert.ErrorIs(t, err, event.ErrNotFound)
+	for _, u := range []event.User{alice, bob} {
+		_, err := repo.GetUser(u.ID)
+		assert.ErrorIs(t, err, event.ErrNotFound)
+		_, err = store.GetForDay(u.ID, testDate)
+		assert.ErrorIs(t, err, event.ErrNotFound)
+	}
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		assert.Nil(t, tx.Bucket(itob(alice.ID)))
+		assert.Nil(t, tx.Bucket(itob(bob.ID)))
+		return nil
+	}))
+
+	// other organization is untouched
+	orgs, err := repo.ListOrgs()
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Equal(t, other.ID, orgs[0].ID)
+	events, err := store.GetForDay(carol.ID, testDate)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	problems, err := Verify(db)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	assert.ErrorIs(t, repo.DeleteOrg(org.ID), event.ErrNotFound)
+}
+
+func TestMigrateUsers(t *testing.T) {
+	db := newTestDB(t)
+	createLegacyUser(t, db, 1, event.Event{Title: "sync", Date: testDate})
+	createLegacyUser(t, db, 2, event.Event{Title: "review", Date: testDate}, event.Event{Title: "release", Date: testDate})
+	createLegacyUser(t, db, 5)
+	store := NewBoltEventRepository(db)
+	repo := NewBoltUserRepository(db)
+
+	// calendars created before organizations are not available until migration
+	_, err := store.GetForDay(2, testDate)
+	assert.ErrorIs(t, err, event.ErrNotFound)
+
+	_, err = MigrateUsers(db, "acme", 3)
+	assert.ErrorIs(t, err, event.ErrNotFound)
+
+	n, err := MigrateUsers(db, "acme", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	events, err := store.GetForDay(2, testDate)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"review", "release"}, eventTitles(events))
+
+	orgs, err := repo.ListOrgs()
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Equal(t, "acme", orgs[0].Name)
+	users, err := repo.ListUsers(orgs[0].ID)
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	for _, u := range users {
+		assert.True(t, u.Active, u.ID)
+		assert.Equal(t, u.ID == 2, u.Admin, u.ID)
+	}
+
+	problems, err := Verify(db)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	// new users don't take migrated ids
+	u, err := repo.CreateUser(event.User{OrgID: orgs[0].ID, Name: "dave"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), u.ID)
+	u, err = repo.CreateUser(event.User{OrgID: orgs[0].ID, Name: "eve"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), u.ID)
+	u, err = repo.CreateUser(event.User{OrgID: orgs[0].ID, Name: "frank"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), u.ID)
+
+	n, err = MigrateUsers(db, "again", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestMigrateUsersDefaultAdmin(t *testing.T) {
+	db := newTestDB(t)
+	createLegacyUser(t, db, 4)
+	createLegacyUser(t, db, 2)
+
+	n, err := MigrateUsers(db, "acme", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	u, err := NewBoltUserRepository(db).GetUser(2)
+	require.NoError(t, err)
+	assert.True(t, u.Admin)
+	u, err = NewBoltUserRepository(db).GetUser(4)
+	require.NoError(t, err)
+	assert.False(t, u.Admin)
+}
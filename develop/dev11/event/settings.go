@@ -0,0 +1,151 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UserSettings are user working hours, they are used to find meeting slots.
+// Working day can't span midnight, WorkStart must be before WorkEnd.
+type UserSettings struct {
+	TimeZone  string    `json:"time_zone" xml:"time_zone"`
+	WorkStart Clock     `json:"work_start" xml:"work_start"`
+	WorkEnd   Clock     `json:"work_end" xml:"work_end"`
+	WorkDays  []Weekday `json:"work_days" xml:"work_day"`
+}
+
+// DefaultUserSettings are used for users that have not set their working hours
+func DefaultUserSettings() UserSettings {
+	return UserSettings{
+		TimeZone:  "UTC",
+		WorkStart: Clock(9 * time.Hour),
+		WorkEnd:   Clock(18 * time.Hour),
+		WorkDays:  []Weekday{Weekday(time.Monday), Weekday(time.Tuesday), Weekday(time.Wednesday), Weekday(time.Thursday), Weekday(time.Friday)},
+	}
+}
+
+// Validate checks that settings are usable
+func (s UserSettings) Validate() error {
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return fmt.Errorf("unknown time zone %q", s.TimeZone)
+	}
+	if s.WorkStart < 0 || s.WorkEnd > Clock(24*time.Hour) || s.WorkStart >= s.WorkEnd {
+		return fmt.Errorf("work start %s must be before work end %s", s.WorkStart, s.WorkEnd)
+	}
+	if len(s.WorkDays) == 0 {
+		return fmt.Errorf("no work days")
+	}
+	return nil
+}
+
+// Location returns settings time zone, UTC is returned for unknown zones
+func (s UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// IsWorkDay reports whether d is one of work days
+func (s UserSettings) IsWorkDay(d time.Weekday) bool {
+	for _, w := range s.WorkDays {
+		if time.Weekday(w) == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Clock is time of day since midnight encoded as text like "09:30"
+type Clock time.Duration
+
+// ParseClock parses time of day in 15:04 format, 24:00 means end of day
+func ParseClock(s string) (Clock, error) {
+	if s == "24:00" {
+		return Clock(24 * time.Hour), nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse time of day %q, use 15:04 format", s)
+	}
+	return Clock(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), nil
+}
+
+// On returns time of day on the date of day in its location, wall clock is kept on DST changes
+func (c Clock) On(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(time.Duration(c)/time.Minute), 0, 0, day.Location())
+}
+
+func (c Clock) String() string {
+	d := time.Duration(c)
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (c *Clock) UnmarshalText(text []byte) error {
+	v, err := ParseClock(string(text))
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// Weekday is time.Weekday encoded as lower case name like "monday"
+type Weekday time.Weekday
+
+// ParseWeekday parses full or three-letter day name, case is ignored
+func ParseWeekday(s string) (Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return Weekday(d), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown week day %q", s)
+}
+
+// ParseWeekdays parses comma-separated or repeated day names
+func ParseWeekdays(values []string) ([]Weekday, error) {
+	days := make([]Weekday, 0, len(values))
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			d, err := ParseWeekday(s)
+			if err != nil {
+				return nil, err
+			}
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+func (d Weekday) String() string {
+	return strings.ToLower(time.Weekday(d).String())
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Weekday) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Weekday) UnmarshalText(text []byte) error {
+	v, err := ParseWeekday(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
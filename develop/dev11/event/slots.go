@@ -0,0 +1,134 @@
+package event
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is a time interval [Start, End)
+type Interval struct {
+	Start time.Time `json:"start" xml:"start"`
+	End   time.Time `json:"end" xml:"end"`
+}
+
+// Duration returns interval length
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// FreeIntervals returns working hours of user in [from, to) that are not taken by events.
+// Events without duration don't take any time.
+func FreeIntervals(s UserSettings, events []Event, from, to time.Time) []Interval {
+	loc := s.Location()
+
+	var work []Interval
+	local := from.In(loc)
+	for day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !s.IsWorkDay(day.Weekday()) {
+			continue
+		}
+		i := clip(Interval{
+			Start: s.WorkStart.On(day),
+			End:   s.WorkEnd.On(day),
+		}, from, to)
+		if i.Start.Before(i.End) {
+			work = append(work, i)
+		}
+	}
+
+	busy := make([]Interval, 0, len(events))
+	for _, e := range events {
+		if e.Duration > 0 {
+			busy = append(busy, Interval{Start: e.Date, End: e.End()})
+		}
+	}
+
+	return subtract(work, busy)
+}
+
+// IntersectIntervals returns intervals that are in both a and b, both must be sorted and not overlap
+func IntersectIntervals(a, b []Interval) []Interval {
+	var result []Interval
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		i2 := clip(a[i], b[j].Start, b[j].End)
+		if i2.Start.Before(i2.End) {
+			result = append(result, i2)
+		}
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// FindSlots returns intervals where every participant is free for at least duration.
+// Intervals are ranked by day, days are taken in loc, and the longest interval of the day goes first.
+func FindSlots(free [][]Interval, duration time.Duration, loc *time.Location) []Interval {
+	if len(free) == 0 {
+		return nil
+	}
+
+	common := free[0]
+	for _, f := range free[1:] {
+		common = IntersectIntervals(common, f)
+	}
+
+	slots := make([]Interval, 0, len(common))
+	for _, i := range common {
+		if i.Duration() >= duration {
+			slots = append(slots, Interval{Start: i.Start.In(loc), End: i.End.In(loc)})
+		}
+	}
+
+	day := func(t time.Time) string {
+		return t.Format("2006-01-02")
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		if di, dj := day(slots[i].Start), day(slots[j].Start); di != dj {
+			return di < dj
+		}
+		return slots[i].Duration() > slots[j].Duration()
+	})
+
+	return slots
+}
+
+// clip returns part of i that is in [from, to), result is empty when they don't overlap
+func clip(i Interval, from, to time.Time) Interval {
+	if i.Start.Before(from) {
+		i.Start = from
+	}
+	if i.End.After(to) {
+		i.End = to
+	}
+	return i
+}
+
+// subtract removes busy intervals from sorted free intervals
+func subtract(free, busy []Interval) []Interval {
+	sort.Slice(busy, func(i, j int) bool {
+		return busy[i].Start.Before(busy[j].Start)
+	})
+
+	var result []Interval
+	for _, f := range free {
+		for _, b := range busy {
+			if !b.End.After(f.Start) || !b.Start.Before(f.End) {
+				continue
+			}
+			if b.Start.After(f.Start) {
+				result = append(result, Interval{Start: f.Start, End: b.Start})
+			}
+			f.Start = b.End
+			if !f.Start.Before(f.End) {
+				break
+			}
+		}
+		if f.Start.Before(f.End) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
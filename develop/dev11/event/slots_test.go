@@ -0,0 +1,88 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeIntervals(t *testing.T) {
+	s := DefaultUserSettings()
+	s.TimeZone = "Europe/Moscow"
+	msk, err := time.LoadLocation(s.TimeZone)
+	require.NoError(t, err)
+
+	// friday and weekend, working hours 09:00-18:00 MSK are 06:00-15:00 UTC
+	from := time.Date(2022, 7, 8, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 7, 11, 0, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Date: time.Date(2022, 7, 8, 7, 0, 0, 0, time.UTC), Duration: Duration(time.Hour)},
+		{Date: time.Date(2022, 7, 8, 7, 30, 0, 0, time.UTC), Duration: Duration(time.Hour)},
+		{Date: time.Date(2022, 7, 8, 10, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2022, 7, 8, 14, 0, 0, 0, time.UTC), Duration: Duration(3 * time.Hour)},
+	}
+
+	got := FreeIntervals(s, events, from, to)
+	require.Len(t, got, 2)
+	assert.True(t, time.Date(2022, 7, 8, 9, 0, 0, 0, msk).Equal(got[0].Start))
+	assert.True(t, time.Date(2022, 7, 8, 10, 0, 0, 0, msk).Equal(got[0].End))
+	assert.True(t, time.Date(2022, 7, 8, 11, 30, 0, 0, msk).Equal(got[1].Start))
+	assert.True(t, time.Date(2022, 7, 8, 17, 0, 0, 0, msk).Equal(got[1].End))
+}
+
+func TestIntersectIntervals(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2022, 7, 8, h, 0, 0, 0, time.UTC)
+	}
+	a := []Interval{{at(9), at(12)}, {at(13), at(18)}}
+	b := []Interval{{at(8), at(10)}, {at(11), at(14)}, {at(17), at(20)}}
+
+	assert.Equal(t, []Interval{{at(9), at(10)}, {at(11), at(12)}, {at(13), at(14)}, {at(17), at(18)}}, IntersectIntervals(a, b))
+	assert.Empty(t, IntersectIntervals(a, nil))
+}
+
+func TestFindSlots(t *testing.T) {
+	at := func(d, h int) time.Time {
+		return time.Date(2022, 7, d, h, 0, 0, 0, time.UTC)
+	}
+	free := [][]Interval{
+		{{at(8, 9), at(12, 12)}},
+		{{at(8, 9), at(8, 10)}, {at(8, 11), at(8, 15)}, {at(11, 9), at(11, 10)}, {at(11, 12), at(11, 13)}},
+	}
+
+	assert.Equal(t, []Interval{
+		{at(8, 11), at(8, 15)},
+		{at(8, 9), at(8, 10)},
+		{at(11, 9), at(11, 10)},
+		{at(11, 12), at(11, 13)},
+	}, FindSlots(free, time.Hour, time.UTC))
+	assert.Equal(t, []Interval{{at(8, 11), at(8, 15)}}, FindSlots(free, 2*time.Hour, time.UTC))
+	assert.Empty(t, FindSlots(nil, time.Hour, time.UTC))
+}
+
+func TestUserSettingsValidate(t *testing.T) {
+	assert.NoError(t, DefaultUserSettings().Validate())
+
+	s := DefaultUserSettings()
+	s.TimeZone = "Mars/Olympus"
+	assert.Error(t, s.Validate())
+
+	s = DefaultUserSettings()
+	s.WorkStart, s.WorkEnd = s.WorkEnd, s.WorkStart
+	assert.Error(t, s.Validate())
+
+	s = DefaultUserSettings()
+	s.WorkDays = nil
+	assert.Error(t, s.Validate())
+}
+
+func TestParseWeekdays(t *testing.T) {
+	days, err := ParseWeekdays([]string{"Mon, tuesday", "sun"})
+	require.NoError(t, err)
+	assert.Equal(t, []Weekday{Weekday(time.Monday), Weekday(time.Tuesday), Weekday(time.Sunday)}, days)
+
+	_, err = ParseWeekdays([]string{"someday"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stats grouping keys accepted by ParseStatsGroup
+const (
+	GroupDay   = "day"
+	GroupWeek  = "week"
+	GroupMonth = "month"
+	GroupTag   = "tag"
+	GroupUser  = "user"
+)
+
+// StatsGroup describes how events are grouped, zero value puts all events in one group
+type StatsGroup struct {
+	// Period is one of GroupDay, GroupWeek, GroupMonth or empty
+	Period string
+	ByTag  bool
+	ByUser bool
+}
+
+// ParseStatsGroup reads grouping keys from comma-separated or repeated values,
+// for example "week,tag". Only one period key is allowed.
+func ParseStatsGroup(values []string) (StatsGroup, error) {
+	var g StatsGroup
+	for _, v := range values {
+		for _, key := range strings.Split(v, ",") {
+			key = strings.ToLower(strings.TrimSpace(key))
+			switch key {
+			case "":
+			case GroupDay, GroupWeek, GroupMonth:
+				if g.Period != "" && g.Period != key {
+					return StatsGroup{}, fmt.Errorf("can't group by both %q and %q", g.Period, key)
+				}
+				g.Period = key
+			case GroupTag:
+				g.ByTag = true
+			case GroupUser:
+				g.ByUser = true
+			default:
+				return StatsGroup{}, fmt.Errorf("unknown group key %q, use %s, %s, %s, %s or %s", key, GroupDay, GroupWeek, GroupMonth, GroupTag, GroupUser)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// Stats is number of events and their total duration in one group.
+// Fields that are not part of grouping are left empty, untagged events have empty Tag.
+type Stats struct {
+	UserID   uint64   `json:"user_id,omitempty" xml:"user_id,omitempty"`
+	Period   string   `json:"period,omitempty" xml:"period,omitempty"`
+	Tag      string   `json:"tag,omitempty" xml:"tag,omitempty"`
+	Count    int      `json:"count" xml:"count"`
+	Duration Duration `json:"duration" xml:"duration"`
+}
+
+// StatsAggregator accumulates events of one or several users into groups
+type StatsAggregator struct {
+	group StatsGroup
+	loc   *time.Location
+	stats map[Stats]*Stats
+}
+
+// NewStatsAggregator creates aggregator, periods are computed in loc
+func NewStatsAggregator(group StatsGroup, loc *time.Location) *StatsAggregator {
+	return &StatsAggregator{
+		group: group,
+		loc:   loc,
+		stats: make(map[Stats]*Stats),
+	}
+}
+
+// Add counts user events. Event is counted in the period it starts in,
+// with ByTag it is counted once for every tag.
+func (a *StatsAggregator) Add(user_id uint64, events []Event) {
+	for _, e := range events {
+		var key Stats
+		if a.group.ByUser {
+			key.UserID = user_id
+		}
+		key.Period = periodKey(e.Date.In(a.loc), a.group.Period)
+
+		if !a.group.ByTag || len(e.Tags) == 0 {
+			a.add(key, e)
+			continue
+		}
+		for _, tag := range e.Tags {
+			key.Tag = tag
+			a.add(key, e)
+		}
+	}
+}
+
+func (a *StatsAggregator) add(key Stats, e Event) {
+	s, ok := a.stats[key]
+	if !ok {
+		s = &Stats{UserID: key.UserID, Period: key.Period, Tag: key.Tag}
+		a.stats[key] = s
+	}
+	s.Count++
+	s.Duration += e.Duration
+}
+
+// Result returns groups sorted by user, period and tag
+func (a *StatsAggregator) Result() []Stats {
+	result := make([]Stats, 0, len(a.stats))
+	for _, s := range a.stats {
+		result = append(result, *s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].UserID != result[j].UserID {
+			return result[i].UserID < result[j].UserID
+		}
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].Tag < result[j].Tag
+	})
+
+	return result
+}
+
+// periodKey formats t as 2022-07-05 for day, 2022-W27 for ISO week and 2022-07 for month
+func periodKey(t time.Time, period string) string {
+	switch period {
+	case GroupDay:
+		return t.Format("2006-01-02")
+	case GroupWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case GroupMonth:
+		return t.Format("2006-01")
+	}
+	return ""
+}
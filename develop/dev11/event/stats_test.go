@@ -0,0 +1,50 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatsGroup(t *testing.T) {
+	g, err := ParseStatsGroup([]string{"Week, tag", "user"})
+	require.NoError(t, err)
+	assert.Equal(t, StatsGroup{Period: GroupWeek, ByTag: true, ByUser: true}, g)
+
+	g, err = ParseStatsGroup(nil)
+	require.NoError(t, err)
+	assert.Equal(t, StatsGroup{}, g)
+
+	_, err = ParseStatsGroup([]string{"day,month"})
+	assert.Error(t, err)
+
+	_, err = ParseStatsGroup([]string{"year"})
+	assert.Error(t, err)
+}
+
+func TestPeriodKey(t *testing.T) {
+	// 2021-01-03 belongs to the last ISO week of 2020
+	d := time.Date(2021, 1, 3, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2021-01-03", periodKey(d, GroupDay))
+	assert.Equal(t, "2020-W53", periodKey(d, GroupWeek))
+	assert.Equal(t, "2021-01", periodKey(d, GroupMonth))
+	assert.Equal(t, "", periodKey(d, ""))
+}
+
+func TestStatsAggregator(t *testing.T) {
+	a := NewStatsAggregator(StatsGroup{Period: GroupMonth}, time.UTC)
+	a.Add(1, []Event{
+		{Date: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), Duration: Duration(time.Hour)},
+		{Date: time.Date(2022, 7, 31, 23, 0, 0, 0, time.UTC), Duration: Duration(time.Hour)},
+	})
+	a.Add(2, []Event{
+		{Date: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), Duration: Duration(time.Minute)},
+	})
+
+	assert.Equal(t, []Stats{
+		{Period: "2022-07", Count: 2, Duration: Duration(2 * time.Hour)},
+		{Period: "2022-08", Count: 1, Duration: Duration(time.Minute)},
+	}, a.Result())
+}
@@ -0,0 +1,106 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TagCount is a tag with the number of events labeled with it
+type TagCount struct {
+	Tag   string `json:"tag" xml:"tag"`
+	Count int    `json:"count" xml:"count"`
+}
+
+// TagFilter selects events by tags, with MatchAll event must have every tag,
+// otherwise any of them. Empty filter matches all events.
+type TagFilter struct {
+	Tags     []string
+	MatchAll bool
+}
+
+// Tag match modes accepted by ParseTagMatch
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// ParseTagMatch converts match mode name to TagFilter.MatchAll value
+func ParseTagMatch(mode string) (bool, error) {
+	switch mode {
+	case "", TagMatchAny:
+		return false, nil
+	case TagMatchAll:
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown tag match mode %q, use %q or %q", mode, TagMatchAny, TagMatchAll)
+}
+
+// NormalizeTags splits comma-separated values, lowercases and trims tags,
+// drops empty and duplicate ones and sorts the result
+func NormalizeTags(values []string) []string {
+	seen := make(map[string]struct{})
+	tags := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t == "" {
+				continue
+			}
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			tags = append(tags, t)
+		}
+	}
+	sort.Strings(tags)
+
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// IsEmpty reports whether filter matches all events
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Tags) == 0
+}
+
+// Match reports whether event passes the filter
+func (f TagFilter) Match(e Event) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	has := make(map[string]struct{}, len(e.Tags))
+	for _, t := range e.Tags {
+		has[t] = struct{}{}
+	}
+	for _, t := range f.Tags {
+		_, ok := has[t]
+		if ok && !f.MatchAll {
+			return true
+		}
+		if !ok && f.MatchAll {
+			return false
+		}
+	}
+
+	return f.MatchAll
+}
+
+// Filter returns events that pass the filter
+func (f TagFilter) Filter(events []Event) []Event {
+	if f.IsEmpty() {
+		return events
+	}
+
+	result := make([]Event, 0, len(events))
+	for _, e := range events {
+		if f.Match(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
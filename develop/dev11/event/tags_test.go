@@ -0,0 +1,33 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"meeting", "oncall", "release"}, NormalizeTags([]string{" Release,meeting", "oncall", "MEETING", ""}))
+	assert.Nil(t, NormalizeTags([]string{" , "}))
+}
+
+func TestTagFilterMatch(t *testing.T) {
+	e := Event{Tags: []string{"meeting", "release"}}
+
+	testCases := []struct {
+		desc   string
+		filter TagFilter
+		want   bool
+	}{
+		{desc: "empty", filter: TagFilter{}, want: true},
+		{desc: "any matched", filter: TagFilter{Tags: []string{"oncall", "release"}}, want: true},
+		{desc: "any not matched", filter: TagFilter{Tags: []string{"oncall"}}, want: false},
+		{desc: "all matched", filter: TagFilter{Tags: []string{"meeting", "release"}, MatchAll: true}, want: true},
+		{desc: "all not matched", filter: TagFilter{Tags: []string{"meeting", "oncall"}, MatchAll: true}, want: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.want, tC.filter.Match(e))
+		})
+	}
+}
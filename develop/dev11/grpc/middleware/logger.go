@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryLogger logs every unary call with its status code and latency
+func UnaryLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamLogger logs every streaming call with its status code and latency
+func StreamLogger(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []zapcore.Field{
+		zap.String("code", code.String()),
+		zap.String("latency", time.Since(start).String()),
+		zap.String("method", method),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		fields = append(fields, zap.String("remote_ip", p.Addr.String()))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	switch code {
+	case codes.OK:
+		zap.L().Info("Success", fields...)
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		zap.L().Error("Server error", fields...)
+	default:
+		zap.L().Warn("Client error", fields...)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"calendar/http/render"
+)
+
+// BearerAuth allows only requests with "Authorization: Bearer <token>" header
+func BearerAuth(token string, next http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			render.ErrorJSON(w, r, http.StatusUnauthorized, fmt.Errorf("unauthorized"), "bad or missing bearer token")
+			return
+		}
+
+		next(w, r)
+	}
+}
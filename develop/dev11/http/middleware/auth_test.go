@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearerAuth(t *testing.T) {
+	handler := BearerAuth("secret", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	testCases := []struct {
+		desc   string
+		header string
+		status int
+	}{
+		{
+			desc:   "valid token",
+			header: "Bearer secret",
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "missing header",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "wrong token",
+			header: "Bearer secreT",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "token prefix",
+			header: "Bearer secre",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "wrong scheme",
+			header: "Basic secret",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "token without scheme",
+			header: "secret",
+			status: http.StatusUnauthorized,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/backup", nil)
+			if tC.header != "" {
+				req.Header.Set("Authorization", tC.header)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			assert.Equal(t, tC.status, rec.Code)
+		})
+	}
+}
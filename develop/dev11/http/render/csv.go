@@ -0,0 +1,169 @@
+package render
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// csvColumn is a path to leaf field of a flattened struct
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// encodeCSV writes struct, slice of structs or map as CSV with a header row.
+// Nested structs are flattened to "parent.child" columns, column names are taken from json tags.
+func encodeCSV(w io.Writer, v interface{}) error {
+	cw := csv.NewWriter(w)
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	switch rv.Kind() {
+	case reflect.Map:
+		// keys are formatted only for ordering and the header, values are looked up by the keys themselves
+		keys := rv.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = csvValue(k)
+		}
+		sort.Sort(mapKeys{keys: keys, names: names})
+
+		row := make([]string, 0, len(keys))
+		for _, k := range keys {
+			row = append(row, csvValue(rv.MapIndex(k)))
+		}
+		if err := cw.Write(names); err != nil {
+			return err
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	case reflect.Slice, reflect.Array:
+		elemType := rv.Type().Elem()
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() != reflect.Struct {
+			return fmt.Errorf("can't encode slice of %s as csv", elemType)
+		}
+
+		columns := csvColumns(elemType, nil, "")
+		if err := cw.Write(csvHeader(columns)); err != nil {
+			return err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := cw.Write(csvRow(reflect.Indirect(rv.Index(i)), columns)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		columns := csvColumns(rv.Type(), nil, "")
+		if err := cw.Write(csvHeader(columns)); err != nil {
+			return err
+		}
+		if err := cw.Write(csvRow(rv, columns)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("can't encode %s as csv", rv.Kind())
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// mapKeys sorts map keys by their formatted names
+type mapKeys struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (m mapKeys) Len() int           { return len(m.keys) }
+func (m mapKeys) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m mapKeys) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func csvColumns(t reflect.Type, index []int, prefix string) []csvColumn {
+	columns := make([]csvColumn, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// fields of embedded unexported structs are still promoted
+		if !f.IsExported() && !(f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name := f.Name
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			name = tag
+		}
+
+		idx := append(append([]int{}, index...), i)
+		isStruct := f.Type.Kind() == reflect.Struct && !f.Type.Implements(textMarshalerType)
+		switch {
+		case isStruct && f.Anonymous && tag == "":
+			columns = append(columns, csvColumns(f.Type, idx, prefix)...)
+		case isStruct:
+			columns = append(columns, csvColumns(f.Type, idx, prefix+name+".")...)
+		default:
+			columns = append(columns, csvColumn{name: prefix + name, index: idx})
+		}
+	}
+
+	return columns
+}
+
+func csvHeader(columns []csvColumn) []string {
+	header := make([]string, 0, len(columns))
+	for _, c := range columns {
+		header = append(header, c.name)
+	}
+	return header
+}
+
+func csvRow(v reflect.Value, columns []csvColumn) []string {
+	row := make([]string, 0, len(columns))
+	for _, c := range columns {
+		row = append(row, csvValue(v.FieldByIndex(c.index)))
+	}
+	return row
+}
+
+// csvValue formats leaf value, slices are joined with ";"
+func csvValue(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(text)
+	}
+
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, csvValue(v.Index(i)))
+		}
+		return strings.Join(items, ";")
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoder writes values in a specific format
+type Encoder interface {
+	// ContentType is the value of Content-Type response header
+	ContentType() string
+	// MediaTypes are media types from Accept header served by the encoder
+	MediaTypes() []string
+	Encode(w io.Writer, v interface{}) error
+}
+
+// encoders are supported encoders in order of preference, the first one is default
+var encoders = []Encoder{
+	jsonEncoder{},
+	xmlEncoder{},
+	csvEncoder{},
+	msgpackEncoder{},
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (jsonEncoder) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+func (jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(true)
+	return enc.Encode(v)
+}
+
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (xmlEncoder) MediaTypes() []string {
+	return []string{"application/xml", "text/xml"}
+}
+
+// xmlList wraps slices, so the document has a single root element
+type xmlList struct {
+	XMLName xml.Name    `xml:"response"`
+	Items   interface{} `xml:"item"`
+}
+
+func (xmlEncoder) Encode(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		return enc.Encode(xmlList{Items: v})
+	}
+
+	return enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "response"}})
+}
+
+// MarshalXML encodes map as elements sorted by key
+func (m JSONMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := e.EncodeElement(m[k], xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (csvEncoder) MediaTypes() []string {
+	return []string{"text/csv"}
+}
+
+func (csvEncoder) Encode(w io.Writer, v interface{}) error {
+	return encodeCSV(w, v)
+}
+
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackEncoder) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (msgpackEncoder) Encode(w io.Writer, v interface{}) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
@@ -0,0 +1,126 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned when none of the accepted media types is supported
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// acceptRange is a single media range from Accept header
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// Negotiate picks encoder for the request Accept header, JSON is used when header is empty
+func Negotiate(r *http.Request) (Encoder, error) {
+	header := r.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		return encoders[0], nil
+	}
+
+	ranges := parseAccept(header)
+	refused := make([]acceptRange, 0)
+	for _, ar := range ranges {
+		if ar.q <= 0 {
+			refused = append(refused, ar)
+		}
+	}
+
+	for _, ar := range ranges {
+		if ar.q <= 0 {
+			continue
+		}
+		for _, enc := range encoders {
+			if ar.accepts(enc, refused) {
+				return enc, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: supported types are %s", ErrNotAcceptable, strings.Join(supportedTypes(), ", "))
+}
+
+// parseAccept parses Accept header and sorts ranges by preference,
+// more specific ranges win when quality is equal
+func parseAccept(header string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+func (ar acceptRange) specificity() int {
+	switch {
+	case ar.typ == "*":
+		return 0
+	case ar.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+// accepts reports whether range matches one of encoder media types, media types
+// refused with zero quality by more specific ranges are not matched by wildcards
+func (ar acceptRange) accepts(enc Encoder, refused []acceptRange) bool {
+	for _, ct := range enc.MediaTypes() {
+		if ar.matches(ct) && !ar.overridden(ct, refused) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ar acceptRange) overridden(mediaType string, refused []acceptRange) bool {
+	for _, r := range refused {
+		if r.specificity() > ar.specificity() && r.matches(mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ar acceptRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (ar.typ == "*" || ar.typ == typ) && (ar.subtype == "*" || ar.subtype == subtype)
+}
+
+func supportedTypes() []string {
+	types := make([]string, 0)
+	for _, enc := range encoders {
+		types = append(types, enc.MediaTypes()...)
+	}
+	return types
+}
@@ -3,6 +3,7 @@ package render
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -31,7 +32,44 @@ func ErrorJSON(w http.ResponseWriter, r *http.Request, httpStatusCode int, err e
 	JSON(w, r, httpStatusCode, JSONMap{"error": err.Error(), "details": details})
 }
 
+// Respond sends response in format negotiated from Accept header,
+// it sends 406 error if the format is not supported
+func Respond(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	enc, err := Negotiate(r)
+	if err != nil {
+		ErrorJSON(w, r, http.StatusNotAcceptable, err, "unsupported Accept header")
+		return
+	}
+
+	write(w, enc, status, v)
+}
+
+// Error sends error in format negotiated from Accept header, json is used if the format is not supported
+func Error(w http.ResponseWriter, r *http.Request, httpStatusCode int, err error, details string) {
+	enc, nerr := Negotiate(r)
+	if errors.Is(nerr, ErrNotAcceptable) {
+		enc = encoders[0]
+	}
+
+	write(w, enc, httpStatusCode, JSONMap{"error": err.Error(), "details": details})
+}
+
 // NoContent sends no content response
 func NoContent(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func write(w http.ResponseWriter, enc Encoder, status int, v interface{}) {
+	buf := &bytes.Buffer{}
+	if err := enc.Encode(buf, v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Header().Add("Vary", "Accept")
+
+	w.WriteHeader(status)
+
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,162 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type testItem struct {
+	ID      uint64    `json:"id" xml:"id"`
+	Title   string    `json:"title" xml:"title"`
+	Date    time.Time `json:"date" xml:"date"`
+	Tags    []string  `json:"tags" xml:"tag"`
+	Skipped string    `json:"-" xml:"-"`
+}
+
+type testWrapper struct {
+	testItem
+	Version uint64   `json:"version"`
+	Prev    testItem `json:"prev"`
+}
+
+var testDate = time.Date(2022, 7, 5, 15, 4, 1, 0, time.UTC)
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		accept  string
+		want    string
+		wantErr error
+	}{
+		{desc: "no header", accept: "", want: "application/json; charset=utf-8"},
+		{desc: "any", accept: "*/*", want: "application/json; charset=utf-8"},
+		{desc: "csv", accept: "text/csv", want: "text/csv; charset=utf-8"},
+		{desc: "text xml", accept: "text/xml", want: "application/xml; charset=utf-8"},
+		{desc: "msgpack alias", accept: "application/x-msgpack", want: "application/msgpack"},
+		{desc: "quality", accept: "application/json;q=0.5, text/csv;q=0.9", want: "text/csv; charset=utf-8"},
+		{desc: "specific wins wildcard", accept: "*/*, application/xml", want: "application/xml; charset=utf-8"},
+		{desc: "subtype wildcard", accept: "text/html, text/*;q=0.8", want: "application/xml; charset=utf-8"},
+		{desc: "zero quality excluded", accept: "application/json;q=0, text/csv", want: "text/csv; charset=utf-8"},
+		{desc: "zero quality excluded from any", accept: "application/json;q=0, */*", want: "application/xml; charset=utf-8"},
+		{desc: "zero quality excluded from subtype wildcard", accept: "application/json;q=0, application/xml;q=0, application/*", want: "application/msgpack"},
+		{desc: "specific range wins zero quality wildcard", accept: "text/*;q=0, text/csv", want: "text/csv; charset=utf-8"},
+		{desc: "zero quality wildcard refuses less specific", accept: "text/*;q=0, */*;q=0.5", want: "application/json; charset=utf-8"},
+		{desc: "unsupported", accept: "text/html", wantErr: ErrNotAcceptable},
+		{desc: "everything refused", accept: "application/json;q=0", wantErr: ErrNotAcceptable},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tC.accept)
+
+			enc, err := Negotiate(req)
+			if tC.wantErr != nil {
+				assert.True(t, errors.Is(err, tC.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, enc.ContentType())
+		})
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	testCases := []struct {
+		desc string
+		v    interface{}
+		want string
+	}{
+		{
+			desc: "slice of structs",
+			v: []testItem{
+				{ID: 1, Title: "a, b", Date: testDate, Tags: []string{"x", "y"}},
+				{ID: 2, Title: "c", Date: testDate},
+			},
+			want: "id,title,date,tags\n" +
+				"1,\"a, b\",2022-07-05T15:04:01Z,x;y\n" +
+				"2,c,2022-07-05T15:04:01Z,\n",
+		},
+		{
+			desc: "empty slice",
+			v:    []testItem{},
+			want: "id,title,date,tags\n",
+		},
+		{
+			desc: "embedded and nested struct",
+			v:    &testWrapper{testItem: testItem{ID: 1}, Version: 2, Prev: testItem{ID: 1, Title: "old"}},
+			want: "id,title,date,tags,version,prev.id,prev.title,prev.date,prev.tags\n" +
+				"1,,0001-01-01T00:00:00Z,,2,1,old,0001-01-01T00:00:00Z,\n",
+		},
+		{
+			desc: "map",
+			v:    JSONMap{"error": "not found", "details": "can't get events"},
+			want: "details,error\ncan't get events,not found\n",
+		},
+		{
+			desc: "map with int keys",
+			v:    map[int]int{2: 20, 1: 10},
+			want: "1,2\n10,20\n",
+		},
+		{
+			desc: "map with text keys",
+			v:    map[time.Time]string{testDate: "a"},
+			want: "2022-07-05T15:04:01Z\na\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, encodeCSV(buf, tC.v))
+			assert.Equal(t, tC.want, buf.String())
+		})
+	}
+}
+
+func TestEncodeXML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := xmlEncoder{}.Encode(buf, []testItem{{ID: 1, Title: "a", Date: testDate, Tags: []string{"x"}}})
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><item><id>1</id><title>a</title><date>2022-07-05T15:04:01Z</date><tag>x</tag></item></response>`, buf.String())
+
+	buf.Reset()
+	err = xmlEncoder{}.Encode(buf, JSONMap{"error": "oops", "details": "bad"})
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><details>bad</details><error>oops</error></response>`, buf.String())
+}
+
+func TestEncodeMsgpack(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, msgpackEncoder{}.Encode(buf, testItem{ID: 1, Title: "a", Date: testDate}))
+
+	got := make(map[string]interface{})
+	require.NoError(t, msgpack.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "a", got["title"])
+	assert.True(t, testDate.Equal(got["date"].(time.Time)))
+}
+
+func TestRespond(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+
+	Respond(rec, req, http.StatusOK, []testItem{})
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	req.Header.Set("Accept", "text/csv")
+	rec = httptest.NewRecorder()
+	Error(rec, req, http.StatusNotFound, errors.New("not found"), "can't get events")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "details,error\ncan't get events,not found\n", rec.Body.String())
+}
@@ -0,0 +1,42 @@
+package tlscert
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	require.NoError(t, GenerateSelfSigned(certFile, keyFile, []string{"localhost", "127.0.0.1"}, time.Hour))
+
+	r, err := NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	first, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(first.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost"}, leaf.DNSNames)
+	assert.Len(t, leaf.IPAddresses, 1)
+
+	require.NoError(t, GenerateSelfSigned(certFile, keyFile, []string{"localhost"}, time.Hour))
+	require.NoError(t, r.Reload())
+	second, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Certificate[0], second.Certificate[0])
+
+	// broken files must not replace loaded certificate
+	r.keyFile = filepath.Join(dir, "missing.pem")
+	assert.Error(t, r.Reload())
+	current, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, second, current)
+}
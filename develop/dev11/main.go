@@ -2,16 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	adminapi "calendar/admin/api"
+	"calendar/event"
 	"calendar/event/api"
+	"calendar/event/api/pb"
 	"calendar/event/repository/bolt"
+	grpcmiddleware "calendar/grpc/middleware"
+	"calendar/http/tlscert"
 )
 
 func main() {
@@ -23,9 +36,27 @@ func main() {
 	zap.ReplaceGlobals(logger)
 
 	logger.Info("reading config")
-	config, err := NewConfig()
+	config, opts, err := NewConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
 		logger.Error("can't decode config", zap.Error(err))
+		os.Exit(2)
+	}
+
+	if opts.PrintConfig {
+		if err := config.Print(os.Stdout); err != nil {
+			logger.Error("can't print config", zap.Error(err))
+		}
+		return
+	}
+
+	if len(opts.Args) > 0 {
+		if err := runCommand(opts.Args[0], opts.Args[1:], config); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", opts.Args[0], err)
+			os.Exit(1)
+		}
 		return
 	}
 
@@ -34,10 +65,17 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	defer db.Close()
 
 	store := bolt.NewBoltEventRepository(db)
-	api := api.NewAPI(store, logger)
-	router := api.NewRouter()
+	users := bolt.NewBoltUserRepository(db)
+	eventAPI := api.NewAPI(store, users, logger)
+	router := eventAPI.NewRouter()
+
+	if config.AdminToken != "" {
+		admin := adminapi.NewAPI(db, config.AdminToken, logger)
+		admin.Register(router)
+	}
 
 	srv := &http.Server{
 		Addr:        config.HTTPServerAddress,
@@ -46,21 +84,126 @@ func main() {
 		IdleTimeout: time.Duration(config.IdleTimeout) * time.Second,
 	}
 
+	var certs *tlscert.Reloader
+	if config.TLSCertFile != "" {
+		certs, err = tlscert.NewReloader(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			logger.Error("can't load tls certificate", zap.Error(err))
+			os.Exit(1)
+		}
+		srv.TLSConfig = certs.ServerConfig()
+	}
+
+	ctx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go purgeTrash(ctx, store, time.Duration(config.TrashRetention)*time.Hour, time.Duration(config.PurgeInterval)*time.Second, logger)
+
 	logger.Info("running http server")
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if certs != nil {
+			// certificate is provided by TLSConfig.GetCertificate
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatal("can't start server", zap.Error(err), zap.String("server address", config.HTTPServerAddress))
 		}
 	}()
 
-	// graceful shutdown
+	var grpcSrv *grpc.Server
+	if config.GRPCServerAddress != "" {
+		opts := []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(grpcmiddleware.UnaryLogger),
+			grpc.ChainStreamInterceptor(grpcmiddleware.StreamLogger),
+		}
+		if certs != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(certs.ServerConfig())))
+		}
+		grpcSrv = grpc.NewServer(opts...)
+		pb.RegisterCalendarServer(grpcSrv, api.NewGRPCServer(store, logger))
+
+		lis, err := net.Listen("tcp", config.GRPCServerAddress)
+		if err != nil {
+			logger.Fatal("can't listen grpc address", zap.Error(err), zap.String("server address", config.GRPCServerAddress))
+		}
+
+		logger.Info("running grpc server")
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				logger.Fatal("can't start grpc server", zap.Error(err), zap.String("server address", config.GRPCServerAddress))
+			}
+		}()
+	}
+
+	// graceful shutdown, SIGHUP reloads tls certificate
 	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	<-stop
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range stop {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if certs == nil {
+			continue
+		}
+		if err := certs.Reload(); err != nil {
+			logger.Error("can't reload tls certificate", zap.Error(err))
+			continue
+		}
+		logger.Info("tls certificate reloaded")
+	}
 	logger.Info("received interrupt signal, closing server")
-	timeout, cancel := context.WithTimeout(context.Background(), time.Duration(5*time.Second))
+	timeout, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeout)*time.Second)
 	defer cancel()
+
+	var wg sync.WaitGroup
+	if grpcSrv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopGRPC(timeout, grpcSrv)
+		}()
+	}
 	if err := srv.Shutdown(timeout); err != nil {
 		logger.Error("can't shutdown http server", zap.Error(err))
 	}
+	wg.Wait()
+}
+
+// stopGRPC waits for active calls to finish and closes them forcibly when ctx expires
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
+
+// purgeTrash periodically removes events that stayed in trash longer than retention
+func purgeTrash(ctx context.Context, store event.EventRepository, retention, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := store.PurgeTrash(time.Now().Add(-retention))
+			if err != nil {
+				logger.Error("can't purge trash", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				logger.Info("purged deleted events", zap.Int("count", n))
+			}
+		}
+	}
 }